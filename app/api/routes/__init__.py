@@ -1,7 +1,41 @@
-from fastapi import APIRouter
-from app.api.routes import webhook, health
+from fastapi import APIRouter, Depends, Security
+from app.api.routes import webhook, health, keys, auth, admin, events, prices, candles, alerts, portfolio, quotes, market_stream, gas, arbitrage, notifications, debug, kv
+from app.core.authorization import authorize
+from app.core.rate_limit import ip_rate_limit, rate_limit
 
 router = APIRouter()
 
-router.include_router(webhook.router, prefix="/webhook", tags=["webhook"])
-router.include_router(health.router, prefix="/health", tags=["health"]) 
\ No newline at end of file
+# Public routes: probes, token exchange and the (polling-mode) Telegram webhook.
+# These are rate limited per client IP.
+public = APIRouter(dependencies=[Depends(rate_limit)])
+public.include_router(webhook.router, prefix="/webhook", tags=["webhook"])
+public.include_router(health.router, prefix="/health", tags=["health"])
+public.include_router(auth.router, tags=["auth"])
+
+# Everything else requires an API key or bearer token. The per-IP limit runs
+# before authorize so bad credentials are throttled too; the per-caller limit
+# runs after it so the limiter knows who is calling
+protected = APIRouter(dependencies=[Depends(ip_rate_limit), Security(authorize), Depends(rate_limit)])
+protected.include_router(keys.router, prefix="/keys", tags=["keys"])
+protected.include_router(auth.protected_router, tags=["auth"])
+protected.include_router(admin.router, prefix="/admin", tags=["admin"])
+protected.include_router(debug.router, prefix="/debug", tags=["admin"])
+protected.include_router(prices.router, prefix="/prices", tags=["market"])
+protected.include_router(candles.router, prefix="/candles", tags=["market"])
+protected.include_router(alerts.router, prefix="/alerts", tags=["alerts"])
+protected.include_router(portfolio.router, prefix="/portfolio", tags=["portfolio"])
+protected.include_router(notifications.router, prefix="/notifications", tags=["notifications"])
+protected.include_router(quotes.router, prefix="/quote", tags=["market"])
+protected.include_router(gas.router, prefix="/gas", tags=["market"])
+protected.include_router(arbitrage.router, prefix="/arbitrage", tags=["market"])
+protected.include_router(kv.router, prefix="/kv", tags=["kv"])
+
+# WebSocket routes authenticate during the handshake themselves; router-level
+# HTTP dependencies don't apply to them
+streaming = APIRouter()
+streaming.include_router(events.router, tags=["events"])
+streaming.include_router(market_stream.router, tags=["market"])
+
+router.include_router(public)
+router.include_router(protected)
+router.include_router(streaming)
\ No newline at end of file
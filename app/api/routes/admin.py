@@ -0,0 +1,115 @@
+from fastapi import APIRouter, Depends, HTTPException, Query, Request
+from fastapi.responses import StreamingResponse
+from dataclasses import asdict
+from typing import Any, Dict, List, Optional
+import asyncio
+import json
+
+from app.core.config_reload import reload_config
+from app.core.log_stream import log_stream
+from app.core.maintenance import maintenance
+from app.core.security import require_totp
+from app.models.schemas import ChainInfo, ChainUpdate, MaintenanceUpdate
+from app.services.cache_service import CacheService
+from app.services.chain_registry import chain_registry
+
+router = APIRouter()
+
+LOG_STREAM_HEARTBEAT = 15  # Seconds between keepalive comments on idle log streams
+
+@router.post("/reload", dependencies=[Depends(require_totp)])
+async def reload() -> Dict:
+    """Reload configuration without restarting the process"""
+    try:
+        reloaded = await reload_config()
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=f"Configuration rejected: {e}")
+    return {"reloaded": reloaded}
+
+@router.get("/maintenance")
+async def maintenance_status() -> Dict[str, Any]:
+    """Whether maintenance mode is on and how many requests are still running"""
+    return maintenance.status()
+
+@router.post("/maintenance", dependencies=[Depends(require_totp)])
+async def set_maintenance(update: MaintenanceUpdate) -> Dict[str, Any]:
+    """Turn maintenance mode on or off; new writes get 503 + Retry-After while on"""
+    if update.enabled:
+        maintenance.enable(update.retry_after, update.reason)
+    else:
+        maintenance.disable()
+    return maintenance.status()
+
+@router.get("/chains", response_model=List[ChainInfo])
+async def list_chains() -> List[ChainInfo]:
+    """Supported chains with the health of each RPC endpoint"""
+    return [ChainInfo(**asdict(chain)) for chain in chain_registry.chains.values()]
+
+@router.post("/chains/check", response_model=List[ChainInfo])
+async def check_chains() -> List[ChainInfo]:
+    """Probe every RPC endpoint now instead of waiting for the next check"""
+    await chain_registry.check_health()
+    return await list_chains()
+
+@router.put("/chains/{name}", response_model=ChainInfo, dependencies=[Depends(require_totp)])
+async def configure_chain(name: str, update: ChainUpdate) -> ChainInfo:
+    """Add a chain or replace its RPC endpoints until restart"""
+    try:
+        chain = chain_registry.configure(name, update.model_dump())
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    return ChainInfo(**asdict(chain))
+
+@router.delete("/chains/{name}", dependencies=[Depends(require_totp)])
+async def reset_chain(name: str) -> Dict:
+    """Drop a runtime change, restoring the configured settings"""
+    if not chain_registry.reset(name):
+        raise HTTPException(status_code=404, detail="Chain has no runtime changes")
+    return {"reset": name.lower()}
+
+@router.get("/cache")
+async def cache_stats() -> Dict[str, Any]:
+    """Cache backend, size and hit rates per key namespace"""
+    return await CacheService().stats()
+
+@router.delete("/cache", dependencies=[Depends(require_totp)])
+async def invalidate_cache(key: Optional[str] = None, prefix: Optional[str] = None) -> Dict:
+    """Invalidate one key, or every key starting with prefix"""
+    cache = CacheService()
+    if key:
+        return {"invalidated": int(await cache.delete_key(key))}
+    if prefix:
+        return {"invalidated": await cache.delete_prefix(prefix)}
+    raise HTTPException(status_code=400, detail="Pass key or prefix")
+
+@router.get("/logs/stream")
+async def stream_logs(
+    request: Request,
+    level: str = "INFO",
+    subsystem: Optional[str] = Query(None, description="Comma-separated module patterns, e.g. app.services.*"),
+):
+    """Tail the server's logs as Server-Sent Events"""
+    patterns = [p.strip() for p in subsystem.split(",") if p.strip()] if subsystem else ["*"]
+    try:
+        subscription = log_stream.subscribe(patterns, level)
+    except ValueError:
+        raise HTTPException(status_code=400, detail=f"Unknown log level {level}")
+
+    async def events():
+        try:
+            while not await request.is_disconnected():
+                try:
+                    entry = await asyncio.wait_for(subscription.queue.get(), timeout=LOG_STREAM_HEARTBEAT)
+                except asyncio.TimeoutError:
+                    # Comment lines keep proxies from closing an idle stream
+                    yield ": keepalive\n\n"
+                    continue
+                yield f"event: log\ndata: {json.dumps(entry)}\n\n"
+        finally:
+            log_stream.unsubscribe(subscription)
+
+    return StreamingResponse(
+        events(),
+        media_type="text/event-stream",
+        headers={"Cache-Control": "no-cache", "X-Accel-Buffering": "no"},
+    )
\ No newline at end of file
@@ -0,0 +1,82 @@
+from fastapi import APIRouter, HTTPException, Query
+from typing import Any, Dict, List, Optional
+
+from app.models.schemas import AlertHistoryEntry, PriceAlertCreate, PriceAlertInfo
+from app.services.alert_service import alert_service
+
+router = APIRouter()
+
+def _to_schema(alert: Dict[str, Any]) -> PriceAlertInfo:
+    return PriceAlertInfo(
+        id=alert["id"],
+        user_id=alert["user_id"],
+        symbol=alert["symbol"],
+        condition=alert["condition"],
+        target_price=alert["target_price"],
+        current_price=alert.get("current_price"),
+        cooldown_seconds=alert.get("cooldown"),
+        last_triggered_at=alert.get("last_triggered_at"),
+        created_at=alert["created_at"],
+    )
+
+def _service_error(result: Dict[str, Any]) -> str:
+    """Strip the chat decoration from alert service error messages"""
+    return result["error"].lstrip("❌ ").strip()
+
+@router.get("", response_model=List[PriceAlertInfo])
+async def list_alerts(user_id: int) -> List[PriceAlertInfo]:
+    """Active alerts for a Telegram user, with current prices"""
+    return [_to_schema(alert) for alert in await alert_service.get_user_alerts(user_id)]
+
+@router.post("", response_model=PriceAlertInfo, status_code=201)
+async def create_alert(payload: PriceAlertCreate) -> PriceAlertInfo:
+    """Create an alert; it notifies the user's Telegram chat when it fires"""
+    result = await alert_service.set_alert(
+        payload.user_id,
+        payload.symbol,
+        payload.target_price,
+        payload.condition.value,
+        cooldown=payload.cooldown_seconds,
+    )
+    if "error" in result:
+        raise HTTPException(status_code=400, detail=_service_error(result))
+    return _to_schema(await alert_service.get_alert(result["alert_id"]))
+
+@router.get("/history", response_model=List[AlertHistoryEntry])
+async def alert_history(
+    user_id: Optional[int] = None,
+    symbol: Optional[str] = None,
+    limit: int = Query(50, ge=1, le=500),
+) -> List[AlertHistoryEntry]:
+    """Past alert triggers, newest first"""
+    events = await alert_service.get_history(user_id=user_id, symbol=symbol, limit=limit)
+    return [
+        AlertHistoryEntry(
+            alert_id=event.alertId,
+            user_id=event.userId,
+            symbol=event.symbol,
+            condition=event.condition,
+            target_price=event.targetPrice,
+            triggered_price=event.triggeredPrice,
+            triggered_at=event.triggeredAt,
+        )
+        for event in events
+    ]
+
+@router.get("/{alert_id}", response_model=PriceAlertInfo)
+async def get_alert(alert_id: str) -> PriceAlertInfo:
+    """Get a single alert"""
+    alert = await alert_service.get_alert(alert_id)
+    if not alert:
+        raise HTTPException(status_code=404, detail="Alert not found")
+    return _to_schema(alert)
+
+@router.delete("/{alert_id}", status_code=204)
+async def delete_alert(alert_id: str):
+    """Delete an alert"""
+    alert = await alert_service.get_alert(alert_id)
+    if not alert:
+        raise HTTPException(status_code=404, detail="Alert not found")
+    result = await alert_service.delete_alert(alert["user_id"], alert_id)
+    if "error" in result:
+        raise HTTPException(status_code=404, detail=_service_error(result))
\ No newline at end of file
@@ -0,0 +1,15 @@
+from fastapi import APIRouter, Query
+from typing import List, Optional
+
+from app.models.schemas import ArbitrageOpportunity
+from app.services.arbitrage_service import arbitrage_service
+
+router = APIRouter()
+
+@router.get("/opportunities", response_model=List[ArbitrageOpportunity])
+async def list_opportunities(
+    symbol: Optional[str] = None,
+    limit: int = Query(50, ge=1, le=500),
+) -> List[ArbitrageOpportunity]:
+    """Recently detected cross-provider spreads above the configured threshold"""
+    return arbitrage_service.recent(symbol, limit)
\ No newline at end of file
@@ -0,0 +1,162 @@
+from fastapi import APIRouter, HTTPException, Request
+from fastapi.responses import RedirectResponse
+from jose import JWTError
+from typing import Dict, Optional
+import hmac
+
+from app.core.security import credential_fingerprint, principal_from_api_key, token_signer
+from app.models.schemas import (
+    RefreshTokenRequest, TokenRequest, TokenResponse,
+    TokenRevocationRequest, TokenIntrospectionRequest, TokenIntrospection,
+    TotpSetup, TotpVerifyRequest,
+)
+from app.services.oidc_service import oidc_service, OIDCError
+from app.services.refresh_token_service import refresh_token_service, RefreshTokenReuseError
+from app.services.token_revocation_service import token_revocation_service
+from app.services.totp_service import totp_service
+
+router = APIRouter()
+
+# Introspection exposes token details, so it is mounted behind authentication
+protected_router = APIRouter()
+
+def _decode_access_token(token: str) -> Optional[Dict]:
+    """Verify an access token, returning None instead of raising"""
+    try:
+        return token_signer.verify(token)
+    except JWTError:
+        return None
+
+@router.post("/token", response_model=TokenResponse)
+async def issue_token(payload: TokenRequest) -> TokenResponse:
+    """Exchange an API key for a short-lived access token and a refresh token"""
+    principal = await principal_from_api_key(payload.api_key)
+    if not principal:
+        raise HTTPException(status_code=401, detail="Invalid API key")
+
+    # Tokens may be narrowed to a subset of the key's scopes, never widened
+    scopes = principal.scopes
+    if payload.scopes is not None:
+        if not principal.has_scopes(payload.scopes):
+            raise HTTPException(status_code=403, detail="Requested scopes exceed those granted to the API key")
+        scopes = payload.scopes
+
+    token = token_signer.issue(principal.subject, scopes)
+    credential_hash = await credential_fingerprint(principal.subject)
+    refresh_token = await refresh_token_service.issue(principal.subject, scopes, credential_hash) if credential_hash else None
+    return TokenResponse(**token, refresh_token=refresh_token)
+
+@router.post("/token/refresh", response_model=TokenResponse)
+async def refresh_token(payload: RefreshTokenRequest) -> TokenResponse:
+    """Rotate a refresh token and issue a new access token"""
+    try:
+        rotated = await refresh_token_service.rotate(payload.refresh_token)
+    except RefreshTokenReuseError:
+        raise HTTPException(status_code=401, detail="Refresh token reuse detected; all tokens in this session were revoked")
+    if not rotated:
+        raise HTTPException(status_code=401, detail="Invalid or expired refresh token")
+
+    new_refresh_token, subject, scopes, credential_hash = rotated
+
+    # Revoking the API key (or rotating ADMIN_API_KEY) also ends sessions derived from it
+    current = await credential_fingerprint(subject)
+    if not current or not credential_hash or not hmac.compare_digest(current, credential_hash):
+        await refresh_token_service.revoke(new_refresh_token)
+        raise HTTPException(status_code=401, detail="The credential this session was issued for is no longer valid")
+
+    token = token_signer.issue(subject, scopes)
+    return TokenResponse(**token, refresh_token=new_refresh_token)
+
+@router.post("/token/revoke", status_code=200)
+async def revoke_token(payload: TokenRevocationRequest) -> Dict:
+    """Revoke an access or refresh token (RFC 7009 semantics).
+
+    Unknown or invalid tokens are not an error, so callers can't probe for
+    valid tokens through this endpoint.
+    """
+    if payload.token_type_hint != "refresh_token":
+        claims = _decode_access_token(payload.token)
+        if claims:
+            await token_revocation_service.revoke(claims["jti"], claims["sub"], claims["exp"])
+            return {}
+
+    await refresh_token_service.revoke(payload.token)
+    return {}
+
+@protected_router.post("/token/introspect", response_model=TokenIntrospection, response_model_exclude_none=True)
+async def introspect_token(payload: TokenIntrospectionRequest) -> TokenIntrospection:
+    """Report whether a token is active and what it grants (RFC 7662 style)"""
+    if payload.token_type_hint != "refresh_token":
+        claims = _decode_access_token(payload.token)
+        if claims:
+            if await token_revocation_service.is_revoked(claims["jti"]):
+                return TokenIntrospection(active=False)
+            return TokenIntrospection(
+                active=True,
+                scope=claims.get("scope", ""),
+                sub=claims["sub"],
+                token_type="access_token",
+                exp=claims["exp"],
+                iat=claims.get("iat"),
+                iss=claims.get("iss"),
+                jti=claims["jti"],
+            )
+
+    record = await refresh_token_service.find_active(payload.token)
+    if not record:
+        return TokenIntrospection(active=False)
+    return TokenIntrospection(
+        active=True,
+        scope=" ".join(record.scopes or []),
+        sub=record.subject,
+        token_type="refresh_token",
+        exp=int(record.expiresAt.timestamp()),
+        iat=int(record.createdAt.timestamp()) if record.createdAt else None,
+    )
+
+@protected_router.post("/auth/2fa/setup", response_model=TotpSetup)
+async def totp_setup(request: Request) -> TotpSetup:
+    """Start TOTP enrollment for the calling principal"""
+    principal = request.state.principal
+    totp = await totp_service.start_enrollment(principal.subject)
+    if not totp:
+        raise HTTPException(status_code=409, detail="Two-factor authentication is already enabled")
+    return TotpSetup(secret=totp.secret, provisioning_uri=totp.provisioning_uri())
+
+@protected_router.post("/auth/2fa/verify")
+async def totp_verify(request: Request, payload: TotpVerifyRequest) -> Dict:
+    """Confirm TOTP enrollment with a code from the authenticator app"""
+    principal = request.state.principal
+    if not await totp_service.confirm_enrollment(principal.subject, payload.code):
+        raise HTTPException(status_code=400, detail="Invalid code or no pending enrollment")
+    return {"enabled": True}
+
+@router.get("/auth/oidc/login")
+async def oidc_login() -> RedirectResponse:
+    """Redirect to the configured identity provider to log in"""
+    if not oidc_service.enabled:
+        raise HTTPException(status_code=404, detail="OIDC login is not configured")
+    return RedirectResponse(await oidc_service.authorization_url())
+
+@router.get("/auth/oidc/callback", response_model=TokenResponse)
+async def oidc_callback(code: str, state: str) -> TokenResponse:
+    """Complete an IdP login and mint the bot's own tokens"""
+    if not oidc_service.enabled:
+        raise HTTPException(status_code=404, detail="OIDC login is not configured")
+    try:
+        claims = await oidc_service.complete_login(code, state)
+    except OIDCError as e:
+        raise HTTPException(status_code=401, detail=str(e))
+
+    scopes = oidc_service.roles_for(claims)
+    if not scopes:
+        raise HTTPException(status_code=403, detail="Your identity provider groups are not mapped to any role")
+
+    # No refresh token: group membership can only be re-checked by logging in again
+    token = token_signer.issue(f"oidc:{claims['sub']}", scopes)
+    return TokenResponse(**token)
+
+@router.get("/.well-known/jwks.json")
+async def jwks() -> Dict:
+    """Public keys for verifying RS256 access tokens"""
+    return token_signer.jwks()
@@ -0,0 +1,32 @@
+from fastapi import APIRouter, HTTPException, Query
+from datetime import datetime, timedelta, timezone
+from typing import Optional
+
+from app.models.schemas import CandlesResponse
+from app.services.candle_service import INTERVALS, candle_service
+
+router = APIRouter()
+
+DEFAULT_CANDLES = 100  # Window returned when "from" is omitted
+
+@router.get("", response_model=CandlesResponse)
+async def get_candles(
+    symbol: str,
+    interval: str = "1h",
+    start: Optional[datetime] = Query(None, alias="from"),
+    end: Optional[datetime] = Query(None, alias="to"),
+) -> CandlesResponse:
+    """OHLCV candles for a symbol, served from the local store"""
+    if interval not in INTERVALS:
+        raise HTTPException(status_code=400, detail=f"interval must be one of {', '.join(INTERVALS)}")
+
+    end = end or datetime.now(timezone.utc)
+    start = start or end - timedelta(seconds=INTERVALS[interval] * (DEFAULT_CANDLES - 1))
+    # Treat naive timestamps as UTC
+    end, start = (t if t.tzinfo else t.replace(tzinfo=timezone.utc) for t in (end, start))
+
+    try:
+        candles = await candle_service.get_candles(symbol, interval, start, end)
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    return CandlesResponse(symbol=symbol.upper(), interval=interval, candles=candles)
\ No newline at end of file
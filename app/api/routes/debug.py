@@ -0,0 +1,81 @@
+from fastapi import APIRouter, Depends, HTTPException, Query, Request
+from typing import Any, Dict, List, Optional
+import httpx
+
+from app.core.security import require_totp
+from app.models.schemas import CapturedRequestInfo
+from app.services.capture_service import REDACTED, capture_service
+
+router = APIRouter()
+
+# Credentials the replay borrows from the admin asking for it
+REPLAY_AUTH_HEADERS = ("authorization", "x-api-key")
+REPLAY_SAFE_METHODS = {"GET", "HEAD", "OPTIONS"}
+
+@router.get("/captures", response_model=List[CapturedRequestInfo])
+async def list_captures(
+    path: Optional[str] = Query(None, description="Only paths starting with this"),
+    limit: int = Query(50, ge=1, le=500),
+) -> List[CapturedRequestInfo]:
+    """Captured request/response pairs, newest first"""
+    return await capture_service.list(path, limit)
+
+@router.get("/captures/{capture_id}", response_model=CapturedRequestInfo)
+async def get_capture(capture_id: str) -> CapturedRequestInfo:
+    capture = await capture_service.get(capture_id)
+    if not capture:
+        raise HTTPException(status_code=404, detail="Capture not found")
+    return capture
+
+@router.delete("/captures", dependencies=[Depends(require_totp)])
+async def purge_captures() -> Dict:
+    """Delete every stored capture"""
+    return {"deleted": await capture_service.purge()}
+
+@router.post("/replay/{capture_id}", dependencies=[Depends(require_totp)])
+async def replay(request: Request, capture_id: str, allow_writes: bool = False) -> Dict[str, Any]:
+    """Re-execute a captured request in-process and compare the outcome.
+
+    Stored credentials are redacted, so the replay runs with the calling
+    admin's own Authorization or X-API-Key header. Requests other than
+    GET/HEAD/OPTIONS need allow_writes=true since they change state again.
+    """
+    capture = await capture_service.get(capture_id)
+    if not capture:
+        raise HTTPException(status_code=404, detail="Capture not found")
+    if capture.body_truncated:
+        raise HTTPException(status_code=409, detail="The captured body was truncated and can't be replayed")
+    if capture.body_redacted:
+        raise HTTPException(status_code=409, detail="The capture had secrets redacted and can't be replayed")
+    if capture.body_summarized:
+        raise HTTPException(status_code=409, detail="The captured body was binary and wasn't stored, so it can't be replayed")
+    if capture.method not in REPLAY_SAFE_METHODS and not allow_writes:
+        raise HTTPException(status_code=409, detail=f"Replaying {capture.method} changes state; pass allow_writes=true")
+
+    headers = {
+        name: value for name, value in capture.request_headers.items()
+        if value != REDACTED and name.lower() not in ("host", "content-length")
+    }
+    headers.update({name: request.headers[name] for name in REPLAY_AUTH_HEADERS if name in request.headers})
+    headers["x-replay-of"] = capture.id
+
+    client_ip = getattr(request.state, "client_ip", None) or "127.0.0.1"
+    transport = httpx.ASGITransport(app=request.app, client=(client_ip, 0))
+    async with httpx.AsyncClient(transport=transport, base_url="http://replay") as client:
+        url = f"{capture.path}?{capture.query}" if capture.query else capture.path
+        response = await client.request(
+            capture.method,
+            url,
+            headers=headers,
+            content=(capture.request_body or "").encode(),
+        )
+
+    return {
+        "capture_id": capture.id,
+        "original": {"status_code": capture.status_code, "body": capture.response_body},
+        "replay": {
+            "status_code": response.status_code,
+            "headers": dict(response.headers),
+            "body": response.text,
+        },
+    }
\ No newline at end of file
@@ -0,0 +1,30 @@
+from fastapi import APIRouter, WebSocket, WebSocketDisconnect, status
+from loguru import logger
+
+from app.core.security import authenticate_websocket
+from app.services.event_bus import event_bus
+
+router = APIRouter()
+
+@router.websocket("/events")
+async def events(websocket: WebSocket, topics: str = "*"):
+    """Stream bot events as JSON messages.
+
+    topics is a comma-separated list of patterns such as "auth.*,alerts.triggered".
+    """
+    principal = await authenticate_websocket(websocket, ["events:read"])
+    if not principal:
+        await websocket.close(code=status.WS_1008_POLICY_VIOLATION)
+        return
+
+    await websocket.accept()
+    subscription = event_bus.subscribe([t.strip() for t in topics.split(",") if t.strip()])
+    logger.info(f"{principal.subject} subscribed to events: {subscription.patterns}")
+    try:
+        while True:
+            event = await subscription.queue.get()
+            await websocket.send_json(event)
+    except WebSocketDisconnect:
+        pass
+    finally:
+        event_bus.unsubscribe(subscription)
\ No newline at end of file
@@ -0,0 +1,16 @@
+from fastapi import APIRouter, HTTPException
+
+from app.models.schemas import GasEstimate
+from app.services.gas_service import GasError, gas_service
+
+router = APIRouter()
+
+@router.get("", response_model=GasEstimate)
+async def get_gas(chain: str = "ethereum") -> GasEstimate:
+    """Current slow/standard/fast network fee estimates for a chain"""
+    try:
+        return await gas_service.get_estimate(chain)
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except GasError as e:
+        raise HTTPException(status_code=502, detail=str(e))
\ No newline at end of file
@@ -1,31 +1,77 @@
-from fastapi import APIRouter, Response
+from fastapi import APIRouter
+from fastapi.responses import JSONResponse
 from loguru import logger
-from typing import Dict
+from typing import Any, Awaitable, Callable, Dict
+import asyncio
+import time
 
 from app.core.db import db
+from app.core.maintenance import maintenance
+from app.core.telegram import bot_instance
 
 router = APIRouter()
+# Kubernetes/load balancer probes, mounted at the application root
+probe_router = APIRouter()
 
-@router.get("")
-async def health_check() -> Dict:
-    """Check system health status"""
-    status = {
-        "status": "healthy",
-        "services": {
-            "database": "unhealthy",
-        }
-    }
+CHECK_TIMEOUT = 2.0  # Seconds before a component counts as down
+
+async def _check_database():
+    await db.connect()
+    await db.execute_raw("SELECT 1")
+
+async def _check_telegram():
+    if not bot_instance.is_running():
+        raise RuntimeError("bot is not polling")
+
+async def _check_alert_checker():
+    if not bot_instance.is_alert_checker_running():
+        raise RuntimeError("alert checker is not running")
+
+async def _check_maintenance():
+    if maintenance.enabled:
+        raise RuntimeError("maintenance mode is on")
 
+READINESS_CHECKS: Dict[str, Callable[[], Awaitable[None]]] = {
+    "database": _check_database,
+    "telegram": _check_telegram,
+    "alert_checker": _check_alert_checker,
+    "maintenance": _check_maintenance,
+}
+
+async def _run_check(name: str, check: Callable[[], Awaitable[None]]) -> Dict[str, Any]:
+    started = time.perf_counter()
     try:
-        # Check database connection
-        await db.connect()
-        await db.disconnect()
-        status["services"]["database"] = "healthy"
+        await asyncio.wait_for(check(), timeout=CHECK_TIMEOUT)
+        result = {"status": "ok"}
+    except asyncio.TimeoutError:
+        result = {"status": "down", "error": "timed out"}
     except Exception as e:
-        logger.error(f"Database health check failed: {e}")
-    # Set overall status
-    if any(v == "unhealthy" for v in status["services"].values()):
-        status["status"] = "unhealthy"
-        return Response(status_code=503, content=status)
+        logger.error(f"{name} readiness check failed: {e}")
+        result = {"status": "down", "error": str(e)}
+    result["latency_ms"] = round((time.perf_counter() - started) * 1000, 1)
+    return result
+
+async def readiness() -> JSONResponse:
+    """Run all component checks; 503 if any of them fails"""
+    results = await asyncio.gather(*(_run_check(name, check) for name, check in READINESS_CHECKS.items()))
+    components = dict(zip(READINESS_CHECKS, results))
+    healthy = all(c["status"] == "ok" for c in components.values())
+    return JSONResponse(
+        status_code=200 if healthy else 503,
+        content={"status": "ok" if healthy else "unavailable", "components": components},
+    )
+
+@probe_router.get("/healthz", include_in_schema=False)
+async def liveness() -> Dict:
+    """Process liveness: answers as long as the event loop is serving requests"""
+    return {"status": "ok"}
 
-    return status 
\ No newline at end of file
+@probe_router.get("/readyz", include_in_schema=False)
+async def ready() -> JSONResponse:
+    """Readiness: database, Telegram polling and the alert checker"""
+    return await readiness()
+
+@router.get("")
+async def health_check() -> JSONResponse:
+    """Check system health status"""
+    return await readiness()
\ No newline at end of file
@@ -0,0 +1,33 @@
+from fastapi import APIRouter, Depends, HTTPException, Request
+from typing import List
+
+from app.core.security import is_known_role, require_totp
+from app.models.schemas import ApiKey, ApiKeyCreate, ApiKeyCreated
+from app.services.api_key_service import api_key_service
+
+router = APIRouter()
+
+@router.post("", response_model=ApiKeyCreated, status_code=201, dependencies=[Depends(require_totp)])
+async def create_key(request: Request, payload: ApiKeyCreate) -> ApiKeyCreated:
+    """Create an API key. The raw key is only returned once."""
+    unknown = [scope for scope in payload.scopes if not is_known_role(scope)]
+    if unknown:
+        raise HTTPException(status_code=400, detail=f"Unknown roles: {', '.join(unknown)}")
+    # Callers can only hand out scopes they hold themselves
+    if not request.state.principal.has_scopes(payload.scopes):
+        raise HTTPException(status_code=403, detail="Cannot grant scopes beyond your own")
+    raw_key, key = await api_key_service.create_key(payload.label, payload.scopes)
+    return ApiKeyCreated(**key.model_dump(), key=raw_key)
+
+@router.get("", response_model=List[ApiKey])
+async def list_keys(include_revoked: bool = False) -> List[ApiKey]:
+    """List API keys without their secret values"""
+    return await api_key_service.list_keys(include_revoked=include_revoked)
+
+@router.delete("/{key_id}", response_model=ApiKey, dependencies=[Depends(require_totp)])
+async def revoke_key(key_id: str) -> ApiKey:
+    """Revoke an API key"""
+    key = await api_key_service.revoke_key(key_id)
+    if not key:
+        raise HTTPException(status_code=404, detail="API key not found")
+    return key
@@ -0,0 +1,94 @@
+from fastapi import APIRouter, Header, HTTPException, Path, Query, Request, Response
+from typing import List, Optional
+
+from app.models.schemas import KvEntry, KvPut
+from app.services.kv_service import KvConflict, KvValueTooLarge, kv_service
+
+router = APIRouter()
+
+# Namespaces are used in scope names, so keep them free of ':' and glob characters
+NAMESPACE_PATTERN = r"^[a-z0-9][a-z0-9_.-]{0,63}$"
+KEY_PATTERN = r"^[A-Za-z0-9_.:@-]{1,256}$"
+
+def _require_namespace_scope(request: Request, namespace: str, access: str):
+    """Require kv:<namespace>:<access>; grants may use wildcards, e.g. kv:*:read"""
+    scope = f"kv:{namespace}:{access}"
+    if not request.state.principal.has_scopes([scope]):
+        raise HTTPException(status_code=403, detail=f"Missing required scopes: {scope}")
+
+def _expected_revision(if_match: Optional[str]) -> Optional[int]:
+    """Parse an If-Match revision; ETags are sent quoted but bare numbers are accepted"""
+    if if_match is None:
+        return None
+    try:
+        return int(if_match.strip().strip('"'))
+    except ValueError:
+        raise HTTPException(status_code=400, detail="If-Match must be a revision number")
+
+def _conflict(e: KvConflict) -> HTTPException:
+    current = "the entry does not exist" if e.revision is None else f"current revision is {e.revision}"
+    return HTTPException(status_code=409, detail=f"Revision mismatch: {current}")
+
+@router.get("/{namespace}", response_model=List[KvEntry])
+async def list_entries(
+    request: Request,
+    namespace: str = Path(..., pattern=NAMESPACE_PATTERN),
+    prefix: str = "",
+    limit: int = Query(100, ge=1, le=1000),
+) -> List[KvEntry]:
+    """Live entries in a namespace, ordered by key"""
+    _require_namespace_scope(request, namespace, "read")
+    return await kv_service.list(namespace, prefix, limit)
+
+@router.get("/{namespace}/{key}", response_model=KvEntry)
+async def get_entry(
+    request: Request,
+    response: Response,
+    namespace: str = Path(..., pattern=NAMESPACE_PATTERN),
+    key: str = Path(..., pattern=KEY_PATTERN),
+) -> KvEntry:
+    _require_namespace_scope(request, namespace, "read")
+    entry = await kv_service.get(namespace, key)
+    if not entry:
+        raise HTTPException(status_code=404, detail="Key not found")
+    response.headers["ETag"] = f'"{entry.revision}"'
+    return entry
+
+@router.put("/{namespace}/{key}", response_model=KvEntry)
+async def put_entry(
+    request: Request,
+    response: Response,
+    payload: KvPut,
+    namespace: str = Path(..., pattern=NAMESPACE_PATTERN),
+    key: str = Path(..., pattern=KEY_PATTERN),
+    if_match: Optional[str] = Header(None),
+) -> KvEntry:
+    """Create or replace an entry.
+
+    Send If-Match with the revision last read to update only if nobody else
+    wrote in between; If-Match: 0 creates the entry only if it doesn't exist.
+    """
+    _require_namespace_scope(request, namespace, "write")
+    try:
+        entry = await kv_service.put(namespace, key, payload.value, payload.ttl, _expected_revision(if_match))
+    except KvConflict as e:
+        raise _conflict(e)
+    except KvValueTooLarge as e:
+        raise HTTPException(status_code=413, detail=str(e))
+    response.headers["ETag"] = f'"{entry.revision}"'
+    return entry
+
+@router.delete("/{namespace}/{key}", status_code=204)
+async def delete_entry(
+    request: Request,
+    namespace: str = Path(..., pattern=NAMESPACE_PATTERN),
+    key: str = Path(..., pattern=KEY_PATTERN),
+    if_match: Optional[str] = Header(None),
+):
+    _require_namespace_scope(request, namespace, "write")
+    try:
+        deleted = await kv_service.delete(namespace, key, _expected_revision(if_match))
+    except KvConflict as e:
+        raise _conflict(e)
+    if not deleted:
+        raise HTTPException(status_code=404, detail="Key not found")
\ No newline at end of file
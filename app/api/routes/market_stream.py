@@ -0,0 +1,55 @@
+from fastapi import APIRouter, WebSocket, WebSocketDisconnect, status
+from loguru import logger
+import asyncio
+
+from app.core.security import authenticate_websocket
+from app.services.market_stream_service import market_stream_service
+
+router = APIRouter()
+
+MAX_SYMBOLS_PER_CONNECTION = 50
+
+async def _read_commands(websocket: WebSocket, subscriber):
+    """Handle {"action": "subscribe"|"unsubscribe", "symbols": [...]} messages"""
+    while True:
+        command = await websocket.receive_json()
+        action = command.get("action")
+        symbols = [str(s) for s in command.get("symbols", [])][:MAX_SYMBOLS_PER_CONNECTION]
+        if action == "subscribe":
+            room = MAX_SYMBOLS_PER_CONNECTION - len(subscriber.symbols)
+            await market_stream_service.subscribe(subscriber, symbols[:max(room, 0)])
+        elif action == "unsubscribe":
+            await market_stream_service.unsubscribe(subscriber, symbols)
+        else:
+            await websocket.send_json({"type": "error", "message": f"Unknown action {action}"})
+            continue
+        await websocket.send_json({"type": "subscriptions", "symbols": sorted(subscriber.symbols)})
+
+async def _write_updates(websocket: WebSocket, subscriber):
+    while True:
+        await websocket.send_json(await subscriber.queue.get())
+
+@router.websocket("/ws/market")
+async def market_stream(websocket: WebSocket):
+    """Live ticker and trade updates for the symbols a client subscribes to"""
+    principal = await authenticate_websocket(websocket, ["prices:read"])
+    if not principal:
+        await websocket.close(code=status.WS_1008_POLICY_VIOLATION)
+        return
+
+    await websocket.accept()
+    subscriber = market_stream_service.connect()
+    tasks = [
+        asyncio.create_task(_read_commands(websocket, subscriber)),
+        asyncio.create_task(_write_updates(websocket, subscriber)),
+    ]
+    try:
+        done, _ = await asyncio.wait(tasks, return_when=asyncio.FIRST_COMPLETED)
+        for task in done:
+            error = task.exception()
+            if error and not isinstance(error, WebSocketDisconnect):
+                logger.warning(f"Market stream for {principal.subject} ended: {error}")
+    finally:
+        for task in tasks:
+            task.cancel()
+        await market_stream_service.disconnect(subscriber)
\ No newline at end of file
@@ -0,0 +1,29 @@
+from fastapi import APIRouter, HTTPException
+from typing import Dict
+
+from app.models.schemas import NotificationSettings
+from app.services.notification_service import notification_service
+
+router = APIRouter()
+
+# Settings belong to Telegram users, addressed by their Telegram ID
+
+@router.get("/{user_id}", response_model=NotificationSettings)
+async def get_settings(user_id: int) -> NotificationSettings:
+    """Where the user's notifications are delivered"""
+    return NotificationSettings(**await notification_service.get_settings(user_id))
+
+@router.put("/{user_id}", response_model=NotificationSettings)
+async def update_settings(user_id: int, settings: NotificationSettings) -> NotificationSettings:
+    """Replace the user's destinations and per-event routes"""
+    try:
+        stored = await notification_service.update_settings(user_id, settings.model_dump())
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    return NotificationSettings(**stored)
+
+@router.post("/{user_id}/test")
+async def send_test(user_id: int) -> Dict:
+    """Send a test notification over the user's routes"""
+    delivered = await notification_service.notify(user_id, "notifications.test", {})
+    return {"delivered": delivered}
\ No newline at end of file
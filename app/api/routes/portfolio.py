@@ -0,0 +1,60 @@
+from fastapi import APIRouter, HTTPException, Query
+from datetime import datetime
+from typing import Dict, List, Optional
+
+from app.models.schemas import PortfolioSnapshotInfo, PortfolioValuation, PositionCreate, WalletCreate, WalletInfo
+from app.services.portfolio_service import portfolio_service
+
+router = APIRouter()
+
+# Portfolios belong to Telegram users, addressed by their Telegram ID
+
+@router.get("/{user_id}", response_model=PortfolioValuation)
+async def get_portfolio(user_id: int) -> PortfolioValuation:
+    """Current holdings, value and allocation"""
+    return await portfolio_service.valuation(user_id)
+
+@router.get("/{user_id}/history", response_model=List[PortfolioSnapshotInfo])
+async def get_history(
+    user_id: int,
+    since: Optional[datetime] = None,
+    limit: int = Query(100, ge=1, le=1000),
+) -> List[PortfolioSnapshotInfo]:
+    """Stored portfolio snapshots, newest first"""
+    return await portfolio_service.history(user_id, since=since, limit=limit)
+
+@router.post("/{user_id}/snapshots", response_model=PortfolioValuation, status_code=201)
+async def take_snapshot(user_id: int) -> PortfolioValuation:
+    """Value the portfolio now and store a snapshot"""
+    return await portfolio_service.snapshot(user_id)
+
+@router.post("/{user_id}/positions", status_code=201)
+async def add_position(user_id: int, payload: PositionCreate) -> Dict:
+    """Add to a position at the given entry price"""
+    position = await portfolio_service.add_position(user_id, payload.symbol, payload.quantity, payload.price)
+    return {"symbol": position.symbol, "quantity": position.quantity, "average_price": position.averagePrice}
+
+@router.delete("/{user_id}/positions/{symbol}", status_code=204)
+async def remove_position(user_id: int, symbol: str, quantity: Optional[float] = Query(None, gt=0)):
+    """Reduce a position by quantity, or close it"""
+    if not await portfolio_service.remove_position(user_id, symbol, quantity):
+        raise HTTPException(status_code=404, detail="Position not found")
+
+@router.get("/{user_id}/wallets", response_model=List[WalletInfo])
+async def list_wallets(user_id: int) -> List[WalletInfo]:
+    """Watched wallets"""
+    return await portfolio_service.list_wallets(user_id)
+
+@router.post("/{user_id}/wallets", response_model=WalletInfo, status_code=201)
+async def add_wallet(user_id: int, payload: WalletCreate) -> WalletInfo:
+    """Watch an on-chain wallet address"""
+    try:
+        return await portfolio_service.add_wallet(user_id, payload.chain, payload.address, payload.label)
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+
+@router.delete("/{user_id}/wallets/{wallet_id}", status_code=204)
+async def remove_wallet(user_id: int, wallet_id: str):
+    """Stop watching a wallet"""
+    if not await portfolio_service.remove_wallet(user_id, wallet_id):
+        raise HTTPException(status_code=404, detail="Wallet not found")
\ No newline at end of file
@@ -0,0 +1,23 @@
+from fastapi import APIRouter, HTTPException, Query
+import re
+
+from app.models.schemas import PricesResponse
+from app.services.market_data_service import market_data_service
+
+router = APIRouter()
+
+MAX_SYMBOLS = 50
+_SYMBOL = re.compile(r"^[A-Za-z0-9]{1,15}$")
+
+@router.get("", response_model=PricesResponse)
+async def get_prices(symbols: str = Query(..., description="Comma-separated symbols, e.g. BTC,ETH")) -> PricesResponse:
+    """Current USD prices from the shared price feed"""
+    requested = [s.strip().upper() for s in symbols.split(",") if s.strip()]
+    if not requested or len(requested) > MAX_SYMBOLS:
+        raise HTTPException(status_code=400, detail=f"Provide between 1 and {MAX_SYMBOLS} symbols")
+    invalid = [s for s in requested if not _SYMBOL.match(s)]
+    if invalid:
+        raise HTTPException(status_code=400, detail=f"Invalid symbols: {', '.join(invalid)}")
+
+    prices = await market_data_service.get_prices(requested)
+    return PricesResponse(prices=prices, missing=[s for s in requested if s not in prices])
\ No newline at end of file
@@ -0,0 +1,22 @@
+from fastapi import APIRouter, HTTPException, Query
+
+from app.models.schemas import SwapQuotesResponse
+from app.services.dex_quote_service import dex_quote_service
+
+router = APIRouter()
+
+@router.get("", response_model=SwapQuotesResponse)
+async def get_quote(
+    sell_token: str = Query(..., alias="from", description="Token address (EVM) or mint (Solana) to sell"),
+    buy_token: str = Query(..., alias="to", description="Token address or mint to buy"),
+    amount: str = Query(..., pattern=r"^[0-9]{1,78}$", description="Sell amount in the token's base units"),
+    chain: str = "ethereum",
+) -> SwapQuotesResponse:
+    """Best swap route across the DEX aggregators configured for the chain"""
+    try:
+        quotes, errors = await dex_quote_service.get_quotes(chain.lower(), sell_token, buy_token, amount)
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    if not quotes:
+        raise HTTPException(status_code=502, detail={"message": "No aggregator returned a quote", "errors": errors})
+    return SwapQuotesResponse(best=quotes[0], quotes=quotes, errors=errors)
\ No newline at end of file
@@ -0,0 +1,81 @@
+from fastapi import HTTPException, Request, Security, status
+from typing import Dict, List, Optional, Tuple
+from loguru import logger
+
+from app.core.security import Principal, get_current_principal
+from app.core.versioning import strip_api_prefix
+
+# Scopes required per (method, route path relative to the API prefix). An
+# empty list means any authenticated caller. Protected routes that are not listed here are denied
+# to everyone except admins.
+PERMISSIONS: Dict[Tuple[str, str], List[str]] = {
+    ("GET", "/keys"): ["keys:manage"],
+    ("POST", "/keys"): ["keys:manage"],
+    ("DELETE", "/keys/{key_id}"): ["keys:manage"],
+    ("POST", "/token/introspect"): ["tokens:introspect"],
+    ("POST", "/auth/2fa/setup"): [],
+    ("POST", "/auth/2fa/verify"): [],
+    ("POST", "/admin/reload"): ["admin"],
+    ("GET", "/admin/maintenance"): ["admin"],
+    ("GET", "/debug/captures"): ["admin"],
+    ("GET", "/debug/captures/{capture_id}"): ["admin"],
+    ("DELETE", "/debug/captures"): ["admin"],
+    ("POST", "/debug/replay/{capture_id}"): ["admin"],
+    ("POST", "/admin/maintenance"): ["admin"],
+    ("GET", "/admin/chains"): ["admin"],
+    ("POST", "/admin/chains/check"): ["admin"],
+    ("PUT", "/admin/chains/{name}"): ["admin"],
+    ("DELETE", "/admin/chains/{name}"): ["admin"],
+    ("GET", "/admin/cache"): ["admin"],
+    ("GET", "/admin/logs/stream"): ["admin"],
+    ("DELETE", "/admin/cache"): ["admin"],
+    ("GET", "/prices"): ["prices:read"],
+    ("GET", "/candles"): ["prices:read"],
+    ("GET", "/alerts"): ["alerts:read"],
+    ("POST", "/alerts"): ["alerts:write"],
+    ("GET", "/alerts/history"): ["alerts:read"],
+    ("GET", "/alerts/{alert_id}"): ["alerts:read"],
+    ("DELETE", "/alerts/{alert_id}"): ["alerts:write"],
+    ("GET", "/quote"): ["prices:read"],
+    ("GET", "/gas"): ["prices:read"],
+    ("GET", "/arbitrage/opportunities"): ["prices:read"],
+    ("GET", "/portfolio/{user_id}"): ["portfolio:read"],
+    ("GET", "/portfolio/{user_id}/history"): ["portfolio:read"],
+    ("POST", "/portfolio/{user_id}/snapshots"): ["portfolio:write"],
+    ("POST", "/portfolio/{user_id}/positions"): ["portfolio:write"],
+    ("DELETE", "/portfolio/{user_id}/positions/{symbol}"): ["portfolio:write"],
+    ("GET", "/portfolio/{user_id}/wallets"): ["portfolio:read"],
+    ("POST", "/portfolio/{user_id}/wallets"): ["portfolio:write"],
+    ("DELETE", "/portfolio/{user_id}/wallets/{wallet_id}"): ["portfolio:write"],
+    ("GET", "/notifications/{user_id}"): ["notifications:read"],
+    ("PUT", "/notifications/{user_id}"): ["notifications:write"],
+    ("POST", "/notifications/{user_id}/test"): ["notifications:write"],
+    # KV routes check kv:<namespace>:read / kv:<namespace>:write themselves
+    ("GET", "/kv/{namespace}"): [],
+    ("GET", "/kv/{namespace}/{key}"): [],
+    ("PUT", "/kv/{namespace}/{key}"): [],
+    ("DELETE", "/kv/{namespace}/{key}"): [],
+}
+
+def required_scopes(method: str, path: str) -> Optional[List[str]]:
+    """Look up the scopes a route requires, or None if it isn't registered"""
+    return PERMISSIONS.get((method.upper(), path))
+
+async def authorize(request: Request, principal: Principal = Security(get_current_principal)) -> Principal:
+    """Central authorization check applied to every protected route"""
+    route = request.scope.get("route")
+    path = strip_api_prefix(getattr(route, "path", request.url.path))
+    scopes = required_scopes(request.method, path)
+
+    if scopes is None:
+        if principal.has_scopes(["admin"]):
+            return principal
+        logger.warning(f"Denied {request.method} {path} for {principal.subject}: route has no permission entry")
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail="Forbidden")
+
+    if not principal.has_scopes(scopes):
+        raise HTTPException(
+            status_code=status.HTTP_403_FORBIDDEN,
+            detail=f"Missing required scopes: {' '.join(scopes)}",
+        )
+    return principal
@@ -0,0 +1,81 @@
+from typing import Any, Dict
+import fnmatch
+import time
+
+from env import env
+from app.services.capture_service import capture_service, redact_body, redact_headers, redact_query
+
+def _should_capture(path: str) -> bool:
+    # Never capture the capture API itself
+    if "/debug/" in path:
+        return False
+    return any(fnmatch.fnmatchcase(path, pattern) for pattern in env.CAPTURE_ROUTES)
+
+class CaptureMiddleware:
+    """Record request/response pairs for the paths in CAPTURE_ROUTES.
+
+    Bodies are kept up to CAPTURE_MAX_BODY_BYTES and secrets are redacted
+    before anything is stored.
+    """
+
+    def __init__(self, app):
+        self.app = app
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] != "http" or not env.CAPTURE_ROUTES or not _should_capture(scope["path"]):
+            return await self.app(scope, receive, send)
+
+        limit = env.CAPTURE_MAX_BODY_BYTES
+        request_body, response_body = bytearray(), bytearray()
+        truncated = False
+        response: Dict[str, Any] = {"status": 500, "headers": []}
+        started = time.perf_counter()
+
+        async def capturing_receive():
+            nonlocal truncated
+            message = await receive()
+            if message["type"] == "http.request":
+                chunk = message.get("body", b"")
+                room = limit - len(request_body)
+                request_body.extend(chunk[:max(room, 0)])
+                truncated = truncated or len(chunk) > room
+            return message
+
+        async def capturing_send(message):
+            if message["type"] == "http.response.start":
+                response["status"] = message["status"]
+                response["headers"] = message.get("headers", [])
+            elif message["type"] == "http.response.body":
+                room = limit - len(response_body)
+                response_body.extend(message.get("body", b"")[:max(room, 0)])
+            await send(message)
+
+        try:
+            await self.app(scope, capturing_receive, capturing_send)
+        finally:
+            headers = {k.decode("latin-1"): v.decode("latin-1") for k, v in scope.get("headers", [])}
+            response_headers = {k.decode("latin-1"): v.decode("latin-1") for k, v in response["headers"]}
+            state = scope.get("state", {})
+            principal = state.get("principal")
+            query = scope.get("query_string", b"").decode("latin-1")
+            masked_query = redact_query(query)
+            body, body_redacted, body_summarized = redact_body(
+                bytes(request_body), headers.get("content-type", ""), request=True
+            )
+            await capture_service.record({
+                "requestId": state.get("request_id"),
+                "method": scope["method"],
+                "path": scope["path"],
+                "query": masked_query,
+                "subject": principal.subject if principal else None,
+                "requestHeaders": redact_headers(headers),
+                "requestBody": body,
+                "bodyTruncated": truncated,
+                # Replay can't reproduce requests whose stored form differs from what was sent
+                "bodyRedacted": body_redacted or masked_query != query,
+                "bodySummarized": body_summarized,
+                "statusCode": response["status"],
+                "responseHeaders": redact_headers(response_headers),
+                "responseBody": redact_body(bytes(response_body), response_headers.get("content-type", ""))[0],
+                "durationMs": round((time.perf_counter() - started) * 1000, 1),
+            })
\ No newline at end of file
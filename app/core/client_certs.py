@@ -0,0 +1,112 @@
+from fastapi import Request
+from typing import Any, Dict, List, Optional, Tuple
+from loguru import logger
+import re
+
+from cryptography import x509
+from cryptography.x509.oid import NameOID
+
+from env import env
+from app.core.ip_filter import ip_filter
+
+def _split_quoted(value: str, separator: str) -> List[str]:
+    """Split on a separator, ignoring separators inside double quotes"""
+    parts, current, quoted = [], [], False
+    for char in value:
+        if char == '"':
+            quoted = not quoted
+        if char == separator and not quoted:
+            parts.append("".join(current))
+            current = []
+        else:
+            current.append(char)
+    parts.append("".join(current))
+    return [part.strip() for part in parts if part.strip()]
+
+def parse_client_cert_header(header: str) -> Dict[str, List[str]]:
+    """Parse the last element of an Envoy-style X-Forwarded-Client-Cert header.
+
+    Returns the element's fields (Subject, URI, DNS, ...) as lists of values.
+    """
+    elements = _split_quoted(header, ",")
+    if not elements:
+        return {}
+    fields: Dict[str, List[str]] = {}
+    for pair in _split_quoted(elements[-1], ";"):
+        name, _, value = pair.partition("=")
+        fields.setdefault(name.strip().lower(), []).append(value.strip().strip('"'))
+    return fields
+
+def _common_name(subject: str) -> Optional[str]:
+    match = re.search(r'(?:^|[,/])\s*CN=([^,/]+)', subject)
+    return match.group(1).strip() if match else None
+
+def certificate_identities(fields: Dict[str, List[str]]) -> List[str]:
+    """Candidate identities of a certificate: its CN followed by its SANs"""
+    identities = []
+    for subject in fields.get("subject", []):
+        cn = _common_name(subject)
+        if cn:
+            identities.append(cn)
+    identities.extend(fields.get("uri", []))
+    identities.extend(fields.get("dns", []))
+    return identities
+
+def fields_from_tls_extension(tls: Dict[str, Any]) -> Dict[str, List[str]]:
+    """Certificate fields from the ASGI TLS extension, in the header parser's format.
+
+    Only called for certificates the server verified against TLS_CLIENT_CA.
+    """
+    chain = tls.get("client_cert_chain") or []
+    if not chain:
+        name = tls.get("client_cert_name")
+        return {"subject": [name]} if name else {}
+
+    cert = x509.load_pem_x509_certificate(chain[0].encode())
+    cns = cert.subject.get_attributes_for_oid(NameOID.COMMON_NAME)
+    fields: Dict[str, List[str]] = {"subject": [f"CN={cns[0].value}"] if cns else []}
+    try:
+        san = cert.extensions.get_extension_for_class(x509.SubjectAlternativeName).value
+        fields["uri"] = san.get_values_for_type(x509.UniformResourceIdentifier)
+        fields["dns"] = san.get_values_for_type(x509.DNSName)
+    except x509.ExtensionNotFound:
+        pass
+    return fields
+
+def _client_cert_fields(request: Request) -> Optional[Dict[str, List[str]]]:
+    """Fields of the caller's verified certificate, from our own TLS listener or a trusted proxy"""
+    tls = request.scope.get("extensions", {}).get("tls")
+    if tls and (tls.get("client_cert_chain") or tls.get("client_cert_name")):
+        if tls.get("client_cert_error"):
+            logger.warning(f"Rejected client certificate: {tls['client_cert_error']}")
+            return None
+        return fields_from_tls_extension(tls)
+
+    header = request.headers.get(env.CLIENT_CERT_HEADER)
+    if not header:
+        return None
+
+    peer = request.client.host if request.client else ""
+    if not ip_filter.policy.is_trusted_proxy(peer):
+        logger.warning(f"Ignoring {env.CLIENT_CERT_HEADER} from untrusted peer {peer}")
+        return None
+    return parse_client_cert_header(header)
+
+def identity_from_client_cert(request: Request) -> Optional[Tuple[str, List[str]]]:
+    """Map a verified client certificate to (identity, scopes).
+
+    The certificate comes from the TLS connection itself when the server
+    terminates mTLS and exposes it through the ASGI TLS extension. Otherwise
+    it comes from the proxy header, which is only trusted when the request
+    arrives directly from one of TRUSTED_PROXIES. That proxy must terminate
+    TLS, verify the client certificate and overwrite the header.
+    """
+    fields = _client_cert_fields(request)
+    if not fields:
+        return None
+
+    for identity in certificate_identities(fields):
+        scopes = env.CLIENT_CERT_IDENTITIES.get(identity)
+        if scopes is not None:
+            return identity, list(scopes)
+    return None
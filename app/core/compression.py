@@ -0,0 +1,34 @@
+from starlette.datastructures import Headers
+from starlette.middleware.gzip import GZipMiddleware, GZipResponder
+from starlette.types import Message
+
+class _EventStreamAwareResponder(GZipResponder):
+    """Passes event-stream responses through untouched, gzips everything else"""
+
+    def __init__(self, *args, **kwargs):
+        super().__init__(*args, **kwargs)
+        self.passthrough = False
+
+    async def send_with_gzip(self, message: Message):
+        if message["type"] == "http.response.start":
+            content_type = Headers(raw=message["headers"]).get("content-type", "")
+            self.passthrough = content_type.startswith("text/event-stream")
+        if self.passthrough:
+            await self.send(message)
+            return
+        await super().send_with_gzip(message)
+
+class StreamingAwareGZipMiddleware(GZipMiddleware):
+    """GZip that leaves Server-Sent Events alone.
+
+    The gzip encoder buffers output, which would hold events back until
+    enough of them piled up to fill a compression block. The decision is
+    made from the response's Content-Type, whatever the client sent as Accept.
+    """
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] == "http" and "gzip" in Headers(scope=scope).get("accept-encoding", ""):
+            responder = _EventStreamAwareResponder(self.app, self.minimum_size, compresslevel=self.compresslevel)
+            await responder(scope, receive, send)
+            return
+        await self.app(scope, receive, send)
\ No newline at end of file
@@ -0,0 +1,63 @@
+from loguru import logger
+from typing import List
+import asyncio
+import signal
+
+from env import env
+from app.core.ip_filter import ip_filter
+from app.core.logging import setup_logging, validate_logging
+from app.core.rate_limit import rate_limiter
+from app.core.security import token_signer
+from app.services.chain_registry import chain_registry
+
+# Only one reload at a time, whether triggered by SIGHUP or the admin API
+_reload_lock = asyncio.Lock()
+
+async def reload_config() -> List[str]:
+    """Reload settings and swap them into the running subsystems.
+
+    Covers rate limits, IP access lists, log levels, chain RPC endpoints, JWT
+    signing keys and every setting read per request (HMAC clients, client
+    certificate identities, OIDC group roles, ...). Raises ValueError if the
+    new configuration is invalid, in which case nothing changes.
+    """
+    async with _reload_lock:
+        previous = dict(env.__dict__)
+        try:
+            env.reload()
+            # Build and validate everything before touching any subsystem
+            limits = rate_limiter.build()
+            signer = token_signer.build()
+            ip_policy = ip_filter.build()
+            chains = chain_registry.build()
+            validate_logging()
+        except ValueError as e:
+            # Roll back so settings and subsystems stay consistent
+            env.__dict__.update(previous)
+            logger.error(f"Configuration reload rejected: {e}")
+            raise
+
+        rate_limiter.reload(limits)
+        token_signer.reload(signer)
+        ip_filter.refresh(ip_policy)
+        setup_logging()
+        chain_registry.refresh(chains)
+        logger.info("Configuration reloaded")
+        return ["settings", "rate_limits", "jwt", "ip_access", "logging", "chains"]
+
+def install_sighup_handler():
+    """Reload configuration when the process receives SIGHUP"""
+    if not hasattr(signal, "SIGHUP"):
+        return
+
+    def _on_sighup():
+        logger.info("SIGHUP received, reloading configuration")
+        asyncio.create_task(_reload_quietly())
+
+    asyncio.get_running_loop().add_signal_handler(signal.SIGHUP, _on_sighup)
+
+async def _reload_quietly():
+    try:
+        await reload_config()
+    except ValueError:
+        pass  # Already logged; keep running with the previous configuration
@@ -0,0 +1,128 @@
+from fastapi import FastAPI, HTTPException, Request
+from fastapi.encoders import jsonable_encoder
+from fastapi.exceptions import RequestValidationError
+from fastapi.responses import JSONResponse, PlainTextResponse, Response
+from starlette.exceptions import HTTPException as StarletteHTTPException
+from typing import Any, Dict, Optional
+from loguru import logger
+
+# Machine-readable error codes for the statuses the API returns
+ERROR_CODES: Dict[int, str] = {
+    400: "bad_request",
+    401: "unauthorized",
+    403: "forbidden",
+    404: "not_found",
+    405: "method_not_allowed",
+    409: "conflict",
+    413: "payload_too_large",
+    422: "policy_violation",
+    429: "rate_limited",
+    500: "internal_error",
+    502: "upstream_error",
+    503: "unavailable",
+    504: "upstream_timeout",
+}
+
+class ApiError(Exception):
+    """An error with an explicit code, for cases the status alone doesn't describe"""
+
+    def __init__(self, status_code: int, message: str, code: Optional[str] = None, details: Any = None):
+        super().__init__(message)
+        self.status_code = status_code
+        self.message = message
+        self.code = code or ERROR_CODES.get(status_code, "error")
+        self.details = details
+
+class PolicyError(ApiError):
+    """A well-formed request refused by a business rule"""
+
+    def __init__(self, message: str, details: Any = None):
+        super().__init__(422, message, code="policy_violation", details=details)
+
+def _wants_plain_text(request: Request) -> bool:
+    accept = request.headers.get("accept", "")
+    return "text/plain" in accept and "application/json" not in accept
+
+def error_response(
+    request: Request,
+    status_code: int,
+    message: str,
+    code: Optional[str] = None,
+    details: Any = None,
+    headers: Optional[Dict[str, str]] = None,
+) -> Response:
+    """Render the error envelope, or a plain text line for clients that ask for it"""
+    code = code or ERROR_CODES.get(status_code, "error")
+    request_id = getattr(request.state, "request_id", None)
+    if _wants_plain_text(request):
+        return PlainTextResponse(f"{code}: {message}", status_code=status_code, headers=headers)
+    return JSONResponse(
+        status_code=status_code,
+        content={
+            "code": code,
+            "message": message,
+            "details": jsonable_encoder(details),
+            "request_id": request_id,
+        },
+        headers=headers,
+    )
+
+async def _api_error_handler(request: Request, exc: ApiError) -> Response:
+    return error_response(request, exc.status_code, exc.message, code=exc.code, details=exc.details)
+
+async def _http_exception_handler(request: Request, exc: StarletteHTTPException) -> Response:
+    message = exc.detail if isinstance(exc.detail, str) else ERROR_CODES.get(exc.status_code, "error")
+    details = None if isinstance(exc.detail, str) else exc.detail
+    return error_response(request, exc.status_code, message, details=details, headers=getattr(exc, "headers", None))
+
+async def _validation_error_handler(request: Request, exc: RequestValidationError) -> Response:
+    return error_response(request, 400, "Request validation failed", code="validation_error", details=exc.errors())
+
+def register_error_handlers(app: FastAPI):
+    """Route all API errors through the common envelope"""
+    app.add_exception_handler(ApiError, _api_error_handler)
+    app.add_exception_handler(StarletteHTTPException, _http_exception_handler)
+    app.add_exception_handler(RequestValidationError, _validation_error_handler)
+
+async def recovery_middleware(request: Request, call_next):
+    """Turn unhandled exceptions into a logged 500 instead of a dropped connection"""
+    try:
+        return await call_next(request)
+    except Exception:
+        logger.exception(f"Unhandled error in {request.method} {request.url.path}")
+        return error_response(request, 500, "Internal server error")
+
+class BodySizeLimitMiddleware:
+    """Reject request bodies larger than max_bytes with 413.
+
+    Declared Content-Length is checked up front; chunked bodies are counted
+    as they are read.
+    """
+
+    def __init__(self, app, max_bytes: int):
+        self.app = app
+        self.max_bytes = max_bytes
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] != "http" or not self.max_bytes:
+            return await self.app(scope, receive, send)
+
+        request = Request(scope)
+        declared = request.headers.get("content-length")
+        if declared and declared.isdigit() and int(declared) > self.max_bytes:
+            response = error_response(request, 413, f"Request body exceeds {self.max_bytes} bytes")
+            return await response(scope, receive, send)
+
+        received = 0
+
+        async def limited_receive():
+            nonlocal received
+            message = await receive()
+            if message["type"] == "http.request":
+                received += len(message.get("body", b""))
+                if received > self.max_bytes:
+                    # HTTPException survives FastAPI's body parsing, which wraps other errors as 400
+                    raise HTTPException(status_code=413, detail=f"Request body exceeds {self.max_bytes} bytes")
+            return message
+
+        await self.app(scope, limited_receive, send)
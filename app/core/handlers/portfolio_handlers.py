@@ -0,0 +1,95 @@
+from telegram import Update
+from telegram.ext import ContextTypes
+from telegram.constants import ParseMode
+from loguru import logger
+
+async def portfolio_command(update: Update, context: ContextTypes.DEFAULT_TYPE, portfolio_service_instance):
+    """Handle /portfolio command"""
+    try:
+        valuation = await portfolio_service_instance.valuation(update.effective_user.id)
+        if not valuation.holdings:
+            await update.message.reply_text(
+                "💼 *Your portfolio is empty*\n\n"
+                "Use `/add <symbol> <quantity> <price>` to add a position.",
+                parse_mode=ParseMode.MARKDOWN
+            )
+            return
+
+        lines = ["💼 *Your Portfolio* 💼\n"]
+        for holding in sorted(valuation.holdings, key=lambda h: h.value_usd or 0, reverse=True):
+            if holding.value_usd is None:
+                lines.append(f"• *{holding.symbol}*: {holding.quantity:,.6g} (no price)")
+                continue
+            lines.append(
+                f"• *{holding.symbol}*: {holding.quantity:,.6g} = ${holding.value_usd:,.2f} "
+                f"({holding.allocation_pct or 0:.1f}%)"
+            )
+
+        pnl_emoji = "📈" if valuation.unrealized_pnl_usd >= 0 else "📉"
+        lines.append(
+            f"\n💰 *Total:* ${valuation.total_value_usd:,.2f}\n"
+            f"{pnl_emoji} *P&L:* ${valuation.unrealized_pnl_usd:+,.2f}"
+        )
+        await update.message.reply_text("\n".join(lines), parse_mode=ParseMode.MARKDOWN)
+
+    except Exception as e:
+        logger.error(f"Error in portfolio command: {e}")
+        await update.message.reply_text("❌ Failed to load your portfolio. Please try again.")
+
+async def add_position_command(update: Update, context: ContextTypes.DEFAULT_TYPE, portfolio_service_instance):
+    """Handle /add command"""
+    if not context.args or len(context.args) < 3:
+        await update.message.reply_text(
+            "*Usage:* `/add <symbol> <quantity> <price>`\n"
+            "*Example:* `/add BTC 0.5 42000`",
+            parse_mode=ParseMode.MARKDOWN
+        )
+        return
+
+    try:
+        symbol = context.args[0].upper()
+        quantity = float(context.args[1].replace(',', ''))
+        price = float(context.args[2].replace(',', ''))
+        if quantity <= 0 or price < 0:
+            raise ValueError("Quantity must be positive and price non-negative")
+    except ValueError:
+        await update.message.reply_text("❌ Quantity and price must be valid positive numbers.")
+        return
+
+    try:
+        position = await portfolio_service_instance.add_position(update.effective_user.id, symbol, quantity, price)
+        await update.message.reply_text(
+            f"✅ *{symbol}* position: {position.quantity:,.6g} @ ${position.averagePrice:,.2f} avg",
+            parse_mode=ParseMode.MARKDOWN
+        )
+    except Exception as e:
+        logger.error(f"Error in add position command: {e}")
+        await update.message.reply_text("❌ Failed to add the position. Please try again.")
+
+async def remove_position_command(update: Update, context: ContextTypes.DEFAULT_TYPE, portfolio_service_instance):
+    """Handle /remove command"""
+    if not context.args:
+        await update.message.reply_text(
+            "*Usage:* `/remove <symbol> [quantity]`\n"
+            "Omit the quantity to close the whole position.",
+            parse_mode=ParseMode.MARKDOWN
+        )
+        return
+
+    symbol = context.args[0].upper()
+    try:
+        quantity = float(context.args[1].replace(',', '')) if len(context.args) > 1 else None
+        if quantity is not None and quantity <= 0:
+            raise ValueError("Quantity must be positive")
+    except ValueError:
+        await update.message.reply_text("❌ Quantity must be a valid positive number.")
+        return
+
+    try:
+        if await portfolio_service_instance.remove_position(update.effective_user.id, symbol, quantity):
+            await update.message.reply_text(f"✅ Updated your *{symbol}* position.", parse_mode=ParseMode.MARKDOWN)
+        else:
+            await update.message.reply_text(f"ℹ️ You don't hold *{symbol}*.", parse_mode=ParseMode.MARKDOWN)
+    except Exception as e:
+        logger.error(f"Error in remove position command: {e}")
+        await update.message.reply_text("❌ Failed to update the position. Please try again.")
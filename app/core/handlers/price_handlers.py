@@ -23,6 +23,27 @@ async def _delete_message_safe(message: Optional[Message]):
         except Exception as e:
             logger.error(f"Error deleting message: {e}")
 
+async def gas_command(update: Update, context: ContextTypes.DEFAULT_TYPE, gas_service_instance):
+    """Handle /gas command"""
+    chain = context.args[0].lower() if context.args else "ethereum"
+    try:
+        estimate = await gas_service_instance.get_estimate(chain)
+        lines = [f"⛽ *Gas on {chain.title()}*\n"]
+        if estimate.base_fee_gwei is not None:
+            lines.append(f"Base fee: {estimate.base_fee_gwei:,.2f} gwei\n")
+        for name, emoji in (("slow", "🐢"), ("standard", "🚗"), ("fast", "🚀")):
+            tier = getattr(estimate, name)
+            line = f"{emoji} *{name.title()}*: {tier.max_fee_gwei:,.2f} gwei"
+            if tier.priority_fee_gwei is not None:
+                line += f" (tip {tier.priority_fee_gwei:,.2f})"
+            lines.append(line)
+        await update.message.reply_text("\n".join(lines), parse_mode=ParseMode.MARKDOWN)
+    except ValueError as ve:
+        await update.message.reply_text(f"❌ {ve}")
+    except Exception as e:
+        logger.error(f"Error in gas command: {e}")
+        await update.message.reply_text("❌ Failed to fetch gas prices. Please try again later.")
+
 async def price_command(update: Update, context: ContextTypes.DEFAULT_TYPE, price_service_instance, symbol: str = None, is_callback: bool = False):
     """Handle /price command with improved formatting"""
     loading_msg = None
@@ -21,6 +21,7 @@ async def help_command(update: Update, context: ContextTypes.DEFAULT_TYPE):
         "/price [symbol] - Current price & stats\n"
         "/coins - List supported coins\n"
         "/history [symbol] [days] - Price history\n"
+        "/gas [chain] - Network gas fees\n"
         "⚡️ Alerts\n"
         "/setalert [symbol] [price] [above/below] - Set alert\n"
         "/alerts - View your alerts\n"
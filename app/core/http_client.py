@@ -0,0 +1,114 @@
+from typing import Any, Dict, Optional
+from email.utils import parsedate_to_datetime
+from datetime import datetime, timezone
+from loguru import logger
+import aiohttp
+import asyncio
+import random
+import ssl
+import httpx
+
+from env import env
+
+RETRY_STATUSES = {429, 502, 503, 504}
+IDEMPOTENT_METHODS = {"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}
+MAX_RETRY_DELAY = 30.0
+
+def ssl_context(ca_bundle: Optional[str] = None, client_cert: Optional[str] = None, client_key: Optional[str] = None) -> ssl.SSLContext:
+    """Verifying TLS context trusting the system store plus an optional CA bundle"""
+    context = ssl.create_default_context()
+    ca_bundle = ca_bundle or env.OUTBOUND_CA_BUNDLE
+    if ca_bundle:
+        context.load_verify_locations(cafile=ca_bundle)
+    if client_cert:
+        context.load_cert_chain(client_cert, client_key or None)
+    return context
+
+def _retry_delay(attempt: int, response: Optional[httpx.Response]) -> float:
+    """Full-jitter exponential backoff, or the server's Retry-After when it sends one"""
+    if response is not None and "retry-after" in response.headers:
+        value = response.headers["retry-after"]
+        try:
+            return min(float(value), MAX_RETRY_DELAY)
+        except ValueError:
+            try:
+                wait = (parsedate_to_datetime(value) - datetime.now(timezone.utc)).total_seconds()
+                return min(max(wait, 0.0), MAX_RETRY_DELAY)
+            except (TypeError, ValueError):
+                pass
+    return random.uniform(0, min(env.OUTBOUND_RETRY_BACKOFF * 2 ** attempt, MAX_RETRY_DELAY))
+
+class RetryTransport(httpx.AsyncBaseTransport):
+    """Retries connection failures and 429/5xx answers to idempotent requests"""
+
+    def __init__(self, transport: httpx.AsyncBaseTransport, retries: int):
+        self._transport = transport
+        self.retries = retries
+
+    async def handle_async_request(self, request: httpx.Request) -> httpx.Response:
+        attempt = 0
+        while True:
+            response = None
+            try:
+                response = await self._transport.handle_async_request(request)
+            except (httpx.ConnectError, httpx.ConnectTimeout):
+                # Nothing reached the server, so any method is safe to retry
+                if attempt >= self.retries:
+                    raise
+            else:
+                if (
+                    attempt >= self.retries
+                    or response.status_code not in RETRY_STATUSES
+                    or request.method not in IDEMPOTENT_METHODS
+                ):
+                    return response
+                await response.aclose()
+
+            delay = _retry_delay(attempt, response)
+            attempt += 1
+            logger.debug(f"Retrying {request.method} {request.url.host} in {delay:.2f}s (attempt {attempt})")
+            await asyncio.sleep(delay)
+
+    async def aclose(self):
+        await self._transport.aclose()
+
+def _transport(verify: ssl.SSLContext, proxy: Optional[str]) -> httpx.AsyncBaseTransport:
+    return RetryTransport(
+        httpx.AsyncHTTPTransport(verify=verify, proxy=httpx.Proxy(proxy) if proxy else None),
+        env.OUTBOUND_RETRIES,
+    )
+
+def create_client(timeout: Optional[float] = None, **kwargs: Any) -> httpx.AsyncClient:
+    """An httpx client using the outbound proxy, TLS and retry settings.
+
+    Hosts in OUTBOUND_TLS_OVERRIDES get their own CA bundle or client
+    certificate; hosts in OUTBOUND_NO_PROXY bypass OUTBOUND_PROXY.
+    Certificate verification can't be turned off.
+    """
+    proxy = env.OUTBOUND_PROXY or None
+    mounts: Dict[str, httpx.AsyncBaseTransport] = {}
+    for host in env.OUTBOUND_NO_PROXY:
+        mounts[f"all://{host}"] = _transport(ssl_context(), None)
+    for host, override in env.OUTBOUND_TLS_OVERRIDES.items():
+        context = ssl_context(override.get("ca_bundle"), override.get("client_cert"), override.get("client_key"))
+        mounts[f"all://{host}"] = _transport(context, None if host in env.OUTBOUND_NO_PROXY else proxy)
+
+    return httpx.AsyncClient(
+        timeout=timeout or env.OUTBOUND_TIMEOUT,
+        transport=_transport(ssl_context(), proxy),
+        mounts=mounts,
+        **kwargs,
+    )
+
+def create_aiohttp_session(**kwargs: Any) -> aiohttp.ClientSession:
+    """An aiohttp session with the outbound CA bundle and timeout.
+
+    aiohttp takes proxies per request, so these sessions use the standard
+    HTTPS_PROXY/NO_PROXY environment variables instead of OUTBOUND_PROXY.
+    """
+    return aiohttp.ClientSession(
+        connector=aiohttp.TCPConnector(ssl=ssl_context()),
+        timeout=aiohttp.ClientTimeout(total=env.OUTBOUND_TIMEOUT),
+        trust_env=True,
+        **kwargs,
+    )
\ No newline at end of file
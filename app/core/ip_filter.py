@@ -0,0 +1,143 @@
+from fastapi import Request
+from typing import Iterable, List, Optional, Union
+from pathlib import Path
+from loguru import logger
+import ipaddress
+import json
+import time
+
+from env import env
+from app.core.errors import error_response
+
+Network = Union[ipaddress.IPv4Network, ipaddress.IPv6Network]
+
+def parse_networks(values: Iterable[str]) -> List[Network]:
+    """Parse IPs or CIDR ranges into networks"""
+    return [ipaddress.ip_network(value.strip(), strict=False) for value in values if value.strip()]
+
+class IPAccessPolicy:
+    """Allow/deny lists plus the proxies whose X-Forwarded-For we trust"""
+
+    def __init__(self, allow: Iterable[str] = (), deny: Iterable[str] = (), trusted_proxies: Iterable[str] = ()):
+        self.allow = parse_networks(allow)
+        self.deny = parse_networks(deny)
+        self.trusted_proxies = parse_networks(trusted_proxies)
+
+    @staticmethod
+    def _matches(ip, networks: List[Network]) -> bool:
+        return any(ip in network for network in networks)
+
+    def is_trusted_proxy(self, address: str) -> bool:
+        """Check whether a direct peer is one of the trusted proxies"""
+        try:
+            return self._matches(ipaddress.ip_address(address), self.trusted_proxies)
+        except ValueError:
+            return False
+
+    def resolve_client_ip(self, peer: str, forwarded_for: Optional[str]) -> str:
+        """Determine the real client address.
+
+        X-Forwarded-For is only honoured when the direct peer is a trusted
+        proxy; the header is then walked right to left, skipping further
+        trusted proxies, so clients can't spoof their address by prepending.
+        """
+        try:
+            ip = ipaddress.ip_address(peer)
+        except ValueError:
+            return peer
+        if not forwarded_for or not self._matches(ip, self.trusted_proxies):
+            return peer
+
+        for hop in reversed([h.strip() for h in forwarded_for.split(",") if h.strip()]):
+            try:
+                hop_ip = ipaddress.ip_address(hop)
+            except ValueError:
+                break
+            if not self._matches(hop_ip, self.trusted_proxies):
+                return hop
+            peer = hop
+        return peer
+
+    def is_allowed(self, address: str) -> bool:
+        """Deny entries win; a non-empty allowlist must match"""
+        try:
+            ip = ipaddress.ip_address(address)
+        except ValueError:
+            # Non-IP peers (e.g. unix sockets, test clients) only pass without an allowlist
+            return not self.allow
+        if self._matches(ip, self.deny):
+            return False
+        return not self.allow or self._matches(ip, self.allow)
+
+class IPFilter:
+    """Holds the active policy, reloading IP_ACCESS_FILE when it changes"""
+    RELOAD_CHECK_INTERVAL = 5  # Seconds between file modification checks
+
+    def __init__(self):
+        self.refresh()
+
+    def build(self) -> IPAccessPolicy:
+        """Parse the configured lists without applying them; raises ValueError"""
+        return self._from_env()
+
+    def refresh(self, policy: Optional[IPAccessPolicy] = None):
+        """Rebuild the policy from the current settings (or a built one) and access file"""
+        self.path = Path(env.IP_ACCESS_FILE) if env.IP_ACCESS_FILE else None
+        self._mtime: Optional[float] = None
+        self._last_check = 0.0
+        self.policy = policy or self._from_env()
+        if self.path:
+            self.reload()
+
+    def _from_env(self) -> IPAccessPolicy:
+        return IPAccessPolicy(
+            allow=env.IP_ALLOWLIST.split(","),
+            deny=env.IP_DENYLIST.split(","),
+            trusted_proxies=env.TRUSTED_PROXIES.split(","),
+        )
+
+    def reload(self) -> bool:
+        """Re-read the access file. A broken file keeps the previous policy."""
+        if not self.path:
+            return False
+        try:
+            self._mtime = self.path.stat().st_mtime
+            data = json.loads(self.path.read_text())
+            self.policy = IPAccessPolicy(
+                allow=data.get("allow", []),
+                deny=data.get("deny", []),
+                trusted_proxies=data.get("trusted_proxies", env.TRUSTED_PROXIES.split(",")),
+            )
+            logger.info(f"IP access policy loaded from {self.path}")
+            return True
+        except Exception as e:
+            logger.error(f"Failed to load IP access policy from {self.path}: {e}")
+            return False
+
+    def maybe_reload(self):
+        """Reload the access file if it was modified since the last load"""
+        if not self.path:
+            return
+        now = time.monotonic()
+        if now - self._last_check < self.RELOAD_CHECK_INTERVAL:
+            return
+        self._last_check = now
+        try:
+            if self.path.stat().st_mtime != self._mtime:
+                self.reload()
+        except OSError as e:
+            logger.error(f"Cannot stat IP access file {self.path}: {e}")
+
+ip_filter = IPFilter()
+
+async def ip_filter_middleware(request: Request, call_next):
+    """Reject requests from denied addresses before any handler runs"""
+    ip_filter.maybe_reload()
+    peer = request.client.host if request.client else ""
+    client_ip = ip_filter.policy.resolve_client_ip(peer, request.headers.get("x-forwarded-for"))
+    request.state.client_ip = client_ip
+
+    if not ip_filter.policy.is_allowed(client_ip):
+        logger.warning(f"Blocked request from {client_ip} to {request.url.path}")
+        return error_response(request, 403, "Forbidden")
+    return await call_next(request)
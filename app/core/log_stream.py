@@ -0,0 +1,65 @@
+from typing import Any, Dict, List, Optional, Set
+from loguru import logger
+import asyncio
+import threading
+
+from app.services.event_bus import Subscription
+
+class LogSubscription(Subscription):
+    """Log records at or above a level from matching subsystems (module names)"""
+    QUEUE_SIZE = 1000
+
+    def __init__(self, patterns: List[str], min_level: int):
+        super().__init__(patterns)
+        self.min_level = min_level
+
+    def accepts(self, entry: Dict[str, Any]) -> bool:
+        return entry["level_no"] >= self.min_level and self.matches(entry["subsystem"])
+
+class LogStream:
+    """Loguru sink that fans log records out to live subscribers"""
+
+    def __init__(self):
+        self._subscriptions: Set[LogSubscription] = set()
+        self._loop: Optional[asyncio.AbstractEventLoop] = None
+        self._loop_thread: Optional[int] = None
+
+    def subscribe(self, patterns: List[str], level: str = "INFO") -> LogSubscription:
+        """Subscribe to records at level or above; raises ValueError for unknown levels"""
+        self._loop = asyncio.get_running_loop()
+        self._loop_thread = threading.get_ident()
+        subscription = LogSubscription(patterns, logger.level(level.upper()).no)
+        self._subscriptions.add(subscription)
+        return subscription
+
+    def unsubscribe(self, subscription: LogSubscription):
+        self._subscriptions.discard(subscription)
+
+    def _dispatch(self, entry: Dict[str, Any]):
+        for subscription in list(self._subscriptions):
+            if subscription.accepts(entry):
+                subscription.offer(entry)
+
+    def sink(self, message):
+        """Called by loguru for every record, possibly from worker threads"""
+        if not self._subscriptions or self._loop is None:
+            return
+        record = message.record
+        entry = {
+            "time": record["time"].isoformat(),
+            "level": record["level"].name,
+            "level_no": record["level"].no,
+            "subsystem": record["name"] or "",
+            "function": record["function"],
+            "line": record["line"],
+            "message": record["message"],
+            "request_id": record["extra"].get("request_id"),
+            "exception": str(record["exception"].value) if record["exception"] else None,
+        }
+        # asyncio queues aren't thread-safe; hop onto the loop when needed
+        if threading.get_ident() == self._loop_thread:
+            self._dispatch(entry)
+        elif not self._loop.is_closed():
+            self._loop.call_soon_threadsafe(self._dispatch, entry)
+
+log_stream = LogStream()
\ No newline at end of file
@@ -1,16 +1,34 @@
 import sys
 from loguru import logger
 from env import env
+from app.core.log_stream import log_stream
+
+def validate_logging():
+    """Check LOG_LEVEL and LOG_LEVELS name known levels; raises ValueError"""
+    for level in [env.LOG_LEVEL, *env.LOG_LEVELS.values()]:
+        if not isinstance(level, int):
+            logger.level(level)
 
 def setup_logging():
     # Remove default handler
     logger.remove()
 
+    # Request ID is bound per request by the request logging middleware
+    logger.configure(extra={"request_id": "-"})
+
+    log_format = "{time:YYYY-MM-DD HH:mm:ss} | {level} | {extra[request_id]} | {name} | {message}"
+    json_output = env.LOG_FORMAT == "json"
+
+    # LOG_LEVELS sets per-module levels on top of the LOG_LEVEL default
+    level_filter = {"": env.LOG_LEVEL, **env.LOG_LEVELS}
+
     # Add console handler with custom format
     logger.add(
         sys.stderr,
-        format="{time:YYYY-MM-DD HH:mm:ss} | {level} | {message}",
-        level=env.LOG_LEVEL,
+        format=log_format,
+        level="TRACE",
+        filter=level_filter,
+        serialize=json_output,
         backtrace=True,
         diagnose=True,
     )
@@ -18,10 +36,11 @@ def setup_logging():
     # Add file handler for errors
     logger.add(
         "logs/error.log",
-        format="{time:YYYY-MM-DD HH:mm:ss} | {level} | {message}",
+        format=log_format,
         level="ERROR",
         rotation="1 day",
         retention="7 days",
+        serialize=json_output,
         backtrace=True,
         diagnose=True,
     )
@@ -29,10 +48,15 @@ def setup_logging():
     # Add file handler for all logs
     logger.add(
         "logs/app.log",
-        format="{time:YYYY-MM-DD HH:mm:ss} | {level} | {message}",
-        level=env.LOG_LEVEL,
+        format=log_format,
+        level="TRACE",
+        filter=level_filter,
         rotation="1 day",
         retention="7 days",
+        serialize=json_output,
     )
 
-    logger.info(f"Logging setup complete. Level: {env.LOG_LEVEL}") 
\ No newline at end of file
+    # Feed GET /admin/logs/stream subscribers
+    logger.add(log_stream.sink, level="TRACE", filter=level_filter, catch=True)
+
+    logger.info(f"Logging setup complete. Level: {env.LOG_LEVEL}, format: {env.LOG_FORMAT}")
\ No newline at end of file
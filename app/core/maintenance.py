@@ -0,0 +1,94 @@
+from fastapi import Request
+from datetime import datetime, timezone
+from typing import Any, Dict, Optional
+from loguru import logger
+
+from app.core.errors import error_response
+from app.core.rate_limit import READ_METHODS
+from app.core.versioning import strip_api_prefix
+
+# Paths that keep working in maintenance mode: probes, metrics and the switch itself
+EXEMPT_PATHS = {"/healthz", "/readyz", "/metrics"}
+# Same, for routes under the API prefix (compared after stripping it)
+EXEMPT_API_ROUTES = {"/admin/maintenance", "/health"}
+
+class MaintenanceMode:
+    """Refuses new state-changing requests while in-flight ones finish.
+
+    Reads keep working so dashboards stay up during a deploy; readiness
+    reports the instance as unavailable so load balancers drain it.
+    """
+
+    def __init__(self):
+        self.enabled = False
+        self.since: Optional[datetime] = None
+        self.retry_after = 60
+        self.reason: Optional[str] = None
+        self.in_flight = 0
+
+    def enable(self, retry_after: int, reason: Optional[str] = None):
+        if not self.enabled:
+            self.since = datetime.now(timezone.utc)
+        self.enabled = True
+        self.retry_after = retry_after
+        self.reason = reason
+        logger.warning(f"Maintenance mode enabled{f': {reason}' if reason else ''}")
+
+    def disable(self):
+        if self.enabled:
+            logger.info("Maintenance mode disabled")
+        self.enabled = False
+        self.since = None
+        self.reason = None
+
+    def status(self) -> Dict[str, Any]:
+        return {
+            "enabled": self.enabled,
+            "since": self.since,
+            "retry_after": self.retry_after,
+            "reason": self.reason,
+            # Excludes the status request itself
+            "in_flight_requests": max(self.in_flight - 1, 0),
+        }
+
+maintenance = MaintenanceMode()
+
+def _is_exempt(request: Request) -> bool:
+    if request.method in READ_METHODS:
+        return True
+    path = request.url.path.rstrip("/") or "/"
+    if path in EXEMPT_PATHS:
+        return True
+    route = strip_api_prefix(path)
+    return route != path and route in EXEMPT_API_ROUTES
+
+class MaintenanceMiddleware:
+    """Count in-flight requests and answer 503 to new writes during maintenance.
+
+    Pure ASGI so a request counts until its whole body has been sent,
+    including streaming responses such as SSE and exports.
+    """
+
+    def __init__(self, app):
+        self.app = app
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] != "http":
+            return await self.app(scope, receive, send)
+
+        request = Request(scope)
+        if maintenance.enabled and not _is_exempt(request):
+            response = error_response(
+                request,
+                503,
+                maintenance.reason or "The service is in maintenance mode",
+                code="maintenance",
+                headers={"Retry-After": str(maintenance.retry_after)},
+            )
+            return await response(scope, receive, send)
+
+        maintenance.in_flight += 1
+        try:
+            await self.app(scope, receive, send)
+        finally:
+            maintenance.in_flight -= 1
\ No newline at end of file
@@ -0,0 +1,75 @@
+from fastapi import Request, Response
+from prometheus_client import CONTENT_TYPE_LATEST, Counter, Gauge, Histogram, generate_latest
+from functools import wraps
+import time
+
+# The default registry already exports process, GC and Python platform metrics
+
+HTTP_REQUESTS = Counter(
+    "http_requests_total",
+    "HTTP requests by route, method and status",
+    ["method", "route", "status"],
+)
+HTTP_REQUEST_DURATION = Histogram(
+    "http_request_duration_seconds",
+    "HTTP request latency by route and method",
+    ["method", "route"],
+)
+HTTP_REQUESTS_IN_FLIGHT = Gauge(
+    "http_requests_in_flight",
+    "HTTP requests currently being handled",
+)
+BOT_COMMAND_DURATION = Histogram(
+    "bot_command_duration_seconds",
+    "Telegram command handling time",
+    ["command"],
+)
+BOT_COMMAND_FAILURES = Counter(
+    "bot_command_failures_total",
+    "Telegram commands whose handler raised",
+    ["command"],
+)
+ALERT_CHECK_DURATION = Histogram(
+    "alert_check_duration_seconds",
+    "Time taken by one run of the alert checker",
+)
+ALERTS_TRIGGERED = Counter(
+    "alerts_triggered_total",
+    "Price alerts that fired",
+)
+
+def route_label(request: Request) -> str:
+    """Route template for labelling, so path parameters don't explode cardinality"""
+    route = request.scope.get("route")
+    return getattr(route, "path", "unmatched")
+
+async def metrics_middleware(request: Request, call_next):
+    """Record request counts, latency and concurrency"""
+    started = time.perf_counter()
+    status_code = 500
+    HTTP_REQUESTS_IN_FLIGHT.inc()
+    try:
+        response = await call_next(request)
+        status_code = response.status_code
+        return response
+    finally:
+        HTTP_REQUESTS_IN_FLIGHT.dec()
+        route = route_label(request)
+        HTTP_REQUESTS.labels(request.method, route, str(status_code)).inc()
+        HTTP_REQUEST_DURATION.labels(request.method, route).observe(time.perf_counter() - started)
+
+def instrument_command(command: str, handler):
+    """Wrap a Telegram command handler to record its duration and failures"""
+    @wraps(handler)
+    async def wrapper(update, context):
+        with BOT_COMMAND_DURATION.labels(command).time():
+            try:
+                return await handler(update, context)
+            except Exception:
+                BOT_COMMAND_FAILURES.labels(command).inc()
+                raise
+    return wrapper
+
+async def metrics_endpoint() -> Response:
+    """Prometheus scrape endpoint"""
+    return Response(content=generate_latest(), media_type=CONTENT_TYPE_LATEST)
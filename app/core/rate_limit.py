@@ -0,0 +1,140 @@
+from fastapi import HTTPException, Request, Response, status
+from collections import OrderedDict
+from dataclasses import dataclass
+from typing import Dict, Optional, Tuple
+import math
+import time
+
+from env import env
+
+READ_METHODS = {"GET", "HEAD", "OPTIONS"}
+
+@dataclass
+class Limit:
+    rate: float  # Tokens added per second
+    burst: int  # Bucket capacity
+
+    @classmethod
+    def parse(cls, value: str) -> 'Limit':
+        """Parse "<requests per second>/<burst>", e.g. "5/10" """
+        rate, _, burst = value.partition("/")
+        limit = cls(rate=float(rate), burst=int(burst or math.ceil(float(rate))))
+        if limit.rate <= 0 or limit.burst < 1:
+            raise ValueError(f"Invalid rate limit: {value}")
+        return limit
+
+@dataclass
+class Bucket:
+    tokens: float
+    updated_at: float
+
+class RateLimiter:
+    """In-process token bucket limiter keyed by caller identity and request class"""
+    MAX_BUCKETS = 10000
+    IDLE_BUCKET_TTL = 600  # Forget callers idle for 10 minutes
+
+    def __init__(self):
+        # Ordered by last use, so idle and least recently seen callers sit at the front
+        self._buckets: 'OrderedDict[Tuple[str, str], Bucket]' = OrderedDict()
+        self.reload()
+
+    def build(self) -> Tuple[Dict[str, Limit], Dict[str, Limit]]:
+        """Parse the configured limits without applying them; raises ValueError"""
+        limits = {
+            "read": Limit.parse(env.RATE_LIMIT_READ),
+            "write": Limit.parse(env.RATE_LIMIT_WRITE),
+            "ip": Limit.parse(env.RATE_LIMIT_PER_IP),
+        }
+        scope_overrides = {
+            scope: Limit.parse(value) for scope, value in env.RATE_LIMIT_SCOPE_OVERRIDES.items()
+        }
+        return limits, scope_overrides
+
+    def reload(self, built: Optional[Tuple[Dict[str, Limit], Dict[str, Limit]]] = None):
+        """Apply the configured (or already built) limits; existing buckets keep their tokens"""
+        self.limits, self.scope_overrides = built or self.build()
+
+    def _prune(self, now: float):
+        """Forget idle callers, then the least recently seen ones past MAX_BUCKETS.
+
+        The hard cap keeps callers rotating IPs or keys from growing the map
+        without bound; an evicted caller just starts again with a full bucket.
+        """
+        cutoff = now - self.IDLE_BUCKET_TTL
+        while self._buckets:
+            oldest = next(iter(self._buckets.values()))
+            if oldest.updated_at >= cutoff and len(self._buckets) < self.MAX_BUCKETS:
+                break
+            self._buckets.popitem(last=False)
+
+    def limit_for(self, request_class: str, scopes: Optional[list] = None) -> Limit:
+        """The caller's limit: the first RATE_LIMIT_SCOPE_OVERRIDES entry matching one
+        of its scopes, stricter or looser than the default, else the default"""
+        held = set(scopes or [])
+        for scope, override in self.scope_overrides.items():
+            if scope in held:
+                return override
+        return self.limits[request_class]
+
+    def hit(self, identity: str, request_class: str, limit: Limit) -> Tuple[bool, float, float]:
+        """Consume a token. Returns (allowed, tokens remaining, seconds until next token)"""
+        now = time.monotonic()
+        self._prune(now)
+
+        key = (identity, request_class)
+        bucket = self._buckets.get(key)
+        if bucket is None:
+            bucket = Bucket(tokens=float(limit.burst), updated_at=now)
+            self._buckets[key] = bucket
+        self._buckets.move_to_end(key)
+
+        bucket.tokens = min(limit.burst, bucket.tokens + (now - bucket.updated_at) * limit.rate)
+        bucket.updated_at = now
+
+        if bucket.tokens >= 1:
+            bucket.tokens -= 1
+            return True, bucket.tokens, 0.0
+        return False, bucket.tokens, (1 - bucket.tokens) / limit.rate
+
+rate_limiter = RateLimiter()
+
+def client_ip(request: Request) -> str:
+    """Client address for keying anonymous callers, as resolved by the IP filter"""
+    ip = getattr(request.state, "client_ip", None)
+    if ip:
+        return ip
+    return request.client.host if request.client else "unknown"
+
+def _enforce(response: Response, identity: str, request_class: str, limit: Limit):
+    """Consume a token, raising 429 when the bucket is empty, and set RateLimit-* headers"""
+    allowed, remaining, retry_after = rate_limiter.hit(identity, request_class, limit)
+    headers = {
+        "RateLimit-Limit": str(limit.burst),
+        "RateLimit-Remaining": str(int(remaining)),
+        "RateLimit-Reset": str(math.ceil((limit.burst - remaining) / limit.rate)),
+    }
+    if not allowed:
+        headers["Retry-After"] = str(math.ceil(retry_after))
+        raise HTTPException(
+            status_code=status.HTTP_429_TOO_MANY_REQUESTS,
+            detail="Rate limit exceeded",
+            headers=headers,
+        )
+    response.headers.update(headers)
+
+async def ip_rate_limit(request: Request, response: Response):
+    """Per-IP limit applied before authentication, so credential guessing is throttled too"""
+    if not env.RATE_LIMIT_ENABLED:
+        return
+    _enforce(response, f"ip:{client_ip(request)}", "ip", rate_limiter.limits["ip"])
+
+async def rate_limit(request: Request, response: Response):
+    """Apply the rate limit for the current caller, setting RateLimit-* headers"""
+    if not env.RATE_LIMIT_ENABLED:
+        return
+
+    principal = getattr(request.state, "principal", None)
+    identity = principal.subject if principal else f"ip:{client_ip(request)}"
+    request_class = "read" if request.method in READ_METHODS else "write"
+    limit = rate_limiter.limit_for(request_class, principal.scopes if principal else None)
+    _enforce(response, identity, request_class, limit)
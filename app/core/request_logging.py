@@ -0,0 +1,42 @@
+from fastapi import Request
+from loguru import logger
+import re
+import time
+import uuid
+
+REQUEST_ID_HEADER = "X-Request-ID"
+# Accept caller-supplied IDs only if they are short and log-safe
+_VALID_REQUEST_ID = re.compile(r"^[A-Za-z0-9._:-]{1,64}$")
+
+def request_id_for(request: Request) -> str:
+    """Reuse a well-formed incoming X-Request-ID or generate a new one"""
+    incoming = request.headers.get(REQUEST_ID_HEADER, "")
+    if _VALID_REQUEST_ID.match(incoming):
+        return incoming
+    return uuid.uuid4().hex
+
+async def request_logging_middleware(request: Request, call_next):
+    """Log one line per request and propagate X-Request-ID"""
+    request_id = request_id_for(request)
+    request.state.request_id = request_id
+    started = time.perf_counter()
+
+    with logger.contextualize(request_id=request_id):
+        status_code = 500
+        try:
+            response = await call_next(request)
+            status_code = response.status_code
+            response.headers[REQUEST_ID_HEADER] = request_id
+            return response
+        finally:
+            principal = getattr(request.state, "principal", None)
+            fields = {
+                "method": request.method,
+                "path": request.url.path,
+                "status": status_code,
+                "latency_ms": round((time.perf_counter() - started) * 1000, 1),
+                "client_ip": getattr(request.state, "client_ip", None),
+                "principal": principal.subject if principal else None,
+            }
+            # Keyword arguments also land in the record's extra for JSON output
+            logger.info("{method} {path} {status} {latency_ms}ms principal={principal}", **fields)
@@ -0,0 +1,304 @@
+from fastapi import Depends, Header, HTTPException, Request, WebSocket, status
+from fastapi.security import HTTPAuthorizationCredentials, HTTPBearer, SecurityScopes
+from jose import jwk, jwt, JWTError
+from dataclasses import dataclass, field
+from datetime import datetime, timedelta, timezone
+from typing import Any, Dict, List, Optional
+from pathlib import Path
+from loguru import logger
+import fnmatch
+import hashlib
+import hmac
+import uuid
+
+from env import env
+from app.core.client_certs import identity_from_client_cert
+from app.core.ip_filter import ip_filter
+from app.core.signing import verify_signed_request
+from app.services.api_key_service import api_key_service
+from app.services.token_revocation_service import token_revocation_service
+from app.services.totp_service import totp_service
+
+ADMIN_SCOPE = "admin"
+ROLE_PREFIX = "role:"
+
+# Roles are shorthands for scope sets; keys and tokens may carry "role:<name>"
+ROLE_SCOPES: Dict[str, List[str]] = {
+    "admin": [ADMIN_SCOPE],
+    "operator": ["*:read", "*:write"],
+    "read-only": ["*:read"],
+}
+
+def expand_scopes(scopes: List[str]) -> List[str]:
+    """Expand role references into the scopes they grant"""
+    expanded = []
+    for scope in scopes:
+        if scope.startswith(ROLE_PREFIX):
+            expanded.extend(ROLE_SCOPES.get(scope[len(ROLE_PREFIX):], []))
+        else:
+            expanded.append(scope)
+    return expanded
+
+def is_known_role(scope: str) -> bool:
+    """Check whether a role reference names a defined role"""
+    return not scope.startswith(ROLE_PREFIX) or scope[len(ROLE_PREFIX):] in ROLE_SCOPES
+
+bearer_scheme = HTTPBearer(auto_error=False)
+
+@dataclass
+class Principal:
+    """An authenticated API caller"""
+    subject: str
+    scopes: List[str] = field(default_factory=list)
+    auth_method: str = "api_key"
+    claims: Dict[str, Any] = field(default_factory=dict)
+
+    def has_scopes(self, scopes: List[str]) -> bool:
+        """Check whether the principal holds all of the given scopes.
+
+        Granted scopes may use wildcards, e.g. "alerts:*" or "*:read".
+        """
+        granted = expand_scopes(self.scopes)
+        if ADMIN_SCOPE in granted:
+            return True
+        return all(
+            any(fnmatch.fnmatchcase(required, pattern) for pattern in granted)
+            for required in expand_scopes(scopes)
+        )
+
+class TokenSigner:
+    """Issues and verifies the JWTs handed out by POST /token"""
+
+    def __init__(self):
+        self.algorithm = env.JWT_ALGORITHM
+        self._signing_key: Optional[str] = None
+        self._verifying_key: Optional[str] = None
+        self.kid: Optional[str] = None
+
+    def _load_keys(self):
+        """Load signing keys lazily so a bad key file fails on first use, not import"""
+        if self._signing_key:
+            return
+
+        if self.algorithm == "HS256":
+            self._signing_key = env.JWT_SECRET
+            self._verifying_key = env.JWT_SECRET
+        elif self.algorithm == "RS256":
+            if not env.JWT_PRIVATE_KEY_FILE or not env.JWT_PUBLIC_KEY_FILE:
+                raise RuntimeError("JWT_PRIVATE_KEY_FILE and JWT_PUBLIC_KEY_FILE must be set for RS256")
+            self._signing_key = Path(env.JWT_PRIVATE_KEY_FILE).read_text()
+            self._verifying_key = Path(env.JWT_PUBLIC_KEY_FILE).read_text()
+            self.kid = hashlib.sha256(self._verifying_key.encode()).hexdigest()[:16]
+        else:
+            raise RuntimeError(f"Unsupported JWT_ALGORITHM: {self.algorithm}")
+
+    def build(self) -> 'TokenSigner':
+        """A signer for the current JWT settings with its keys loaded; raises ValueError"""
+        candidate = TokenSigner()
+        try:
+            candidate._load_keys()
+        except (RuntimeError, OSError) as e:
+            raise ValueError(f"Invalid JWT configuration: {e}")
+        return candidate
+
+    def reload(self, candidate: Optional['TokenSigner'] = None):
+        """Switch to the current JWT settings, or to an already built signer"""
+        candidate = candidate or self.build()
+        self.algorithm = candidate.algorithm
+        self._signing_key, self._verifying_key, self.kid = candidate._signing_key, candidate._verifying_key, candidate.kid
+
+    def issue(self, subject: str, scopes: List[str], ttl: Optional[int] = None) -> Dict[str, Any]:
+        """Issue a signed access token and return it with its metadata"""
+        self._load_keys()
+        now = datetime.now(timezone.utc)
+        expires_in = ttl or env.JWT_ACCESS_TOKEN_TTL
+        claims = {
+            "iss": env.JWT_ISSUER,
+            "sub": subject,
+            "scope": " ".join(scopes),
+            "iat": int(now.timestamp()),
+            "exp": int((now + timedelta(seconds=expires_in)).timestamp()),
+            "jti": str(uuid.uuid4()),
+        }
+        headers = {"kid": self.kid} if self.kid else None
+        token = jwt.encode(claims, self._signing_key, algorithm=self.algorithm, headers=headers)
+        return {
+            "access_token": token,
+            "token_type": "bearer",
+            "expires_in": expires_in,
+            "scope": claims["scope"],
+            "jti": claims["jti"],
+        }
+
+    def verify(self, token: str) -> Dict[str, Any]:
+        """Verify a token's signature, expiry and issuer and return its claims"""
+        self._load_keys()
+        return jwt.decode(
+            token,
+            self._verifying_key,
+            algorithms=[self.algorithm],
+            issuer=env.JWT_ISSUER,
+        )
+
+    def jwks(self) -> Dict[str, Any]:
+        """Return the public JWKS document (empty for symmetric algorithms)"""
+        self._load_keys()
+        if self.algorithm != "RS256":
+            return {"keys": []}
+        key = jwk.construct(self._verifying_key, algorithm="RS256").to_dict()
+        key.update({"kid": self.kid, "use": "sig", "alg": "RS256"})
+        return {"keys": [key]}
+
+token_signer = TokenSigner()
+
+async def principal_from_api_key(raw_key: str) -> Optional[Principal]:
+    """Resolve an API key (bootstrap admin key or stored key) to a principal"""
+    if env.ADMIN_API_KEY and hmac.compare_digest(raw_key, env.ADMIN_API_KEY):
+        return Principal(subject="admin", scopes=[ADMIN_SCOPE])
+
+    key = await api_key_service.verify_key(raw_key)
+    if key:
+        return Principal(subject=f"key:{key.id}", scopes=key.scopes)
+    return None
+
+async def credential_fingerprint(subject: str) -> Optional[str]:
+    """Fingerprint of the credential currently backing a subject, for refresh tokens.
+
+    Returns None for subjects whose credential can't be re-checked later
+    (e.g. OIDC logins, where group membership lives at the IdP); those don't
+    get refresh tokens.
+    """
+    if subject == "admin":
+        return hashlib.sha256(env.ADMIN_API_KEY.encode()).hexdigest() if env.ADMIN_API_KEY else None
+    if subject.startswith("key:"):
+        return await api_key_service.fingerprint(subject[len("key:"):])
+    return None
+
+async def principal_from_token(token: str) -> Optional[Principal]:
+    """Resolve a bearer JWT to a principal, rejecting revoked tokens"""
+    try:
+        claims = token_signer.verify(token)
+    except JWTError as e:
+        logger.debug(f"Rejected bearer token: {e}")
+        return None
+    if await token_revocation_service.is_revoked(claims.get("jti", "")):
+        logger.debug(f"Rejected revoked bearer token {claims.get('jti')}")
+        return None
+    return Principal(
+        subject=claims["sub"],
+        scopes=claims.get("scope", "").split(),
+        auth_method="jwt",
+        claims=claims,
+    )
+
+async def principal_from_signature(request: Request) -> Optional[Principal]:
+    """Resolve an HMAC-signed request to a principal"""
+    verified = await verify_signed_request(request)
+    if not verified:
+        return None
+    client_id, scopes = verified
+    return Principal(subject=f"hmac:{client_id}", scopes=scopes, auth_method="hmac")
+
+async def _authenticate(
+    request: Request,
+    credentials: Optional[HTTPAuthorizationCredentials],
+    x_api_key: Optional[str],
+) -> Optional[Principal]:
+    """Resolve request credentials to a principal"""
+    if env.AUTH_DISABLED:
+        return Principal(subject="dev", scopes=[ADMIN_SCOPE], auth_method="none")
+    if credentials:
+        return await principal_from_token(credentials.credentials)
+    if x_api_key:
+        return await principal_from_api_key(x_api_key)
+    if env.CLIENT_CERT_IDENTITIES:
+        cert_identity = identity_from_client_cert(request)
+        if cert_identity:
+            identity, scopes = cert_identity
+            return Principal(subject=f"cert:{identity}", scopes=scopes, auth_method="client_cert")
+    if env.HMAC_CLIENTS:
+        return await principal_from_signature(request)
+    return None
+
+async def get_current_principal(
+    request: Request,
+    security_scopes: SecurityScopes,
+    credentials: Optional[HTTPAuthorizationCredentials] = Depends(bearer_scheme),
+    x_api_key: Optional[str] = Header(None),
+) -> Principal:
+    """Authenticate the caller and check the scopes declared by the route"""
+    # Router- and route-level dependencies both land here; authenticate only once
+    principal = getattr(request.state, "principal", None)
+    if principal is None:
+        principal = await _authenticate(request, credentials, x_api_key)
+        request.state.principal = principal
+
+    if not principal:
+        raise HTTPException(
+            status_code=status.HTTP_401_UNAUTHORIZED,
+            detail="Invalid or missing credentials",
+            headers={"WWW-Authenticate": "Bearer"},
+        )
+
+    if not principal.has_scopes(security_scopes.scopes):
+        raise HTTPException(
+            status_code=status.HTTP_403_FORBIDDEN,
+            detail=f"Missing required scopes: {' '.join(security_scopes.scopes)}",
+        )
+    return principal
+
+async def authenticate_websocket(websocket: WebSocket, scopes: List[str]) -> Optional[Principal]:
+    """Authenticate a WebSocket handshake and check scopes.
+
+    HTTP middleware doesn't run for WebSockets, so the IP policy is applied
+    here too. Browsers can't set headers on WebSockets, so a bearer token is
+    also accepted as the access_token query parameter.
+    """
+    peer = websocket.client.host if websocket.client else ""
+    client_ip = ip_filter.policy.resolve_client_ip(peer, websocket.headers.get("x-forwarded-for"))
+    if not ip_filter.policy.is_allowed(client_ip):
+        logger.warning(f"Blocked WebSocket from {client_ip} to {websocket.url.path}")
+        return None
+
+    if env.AUTH_DISABLED:
+        principal = Principal(subject="dev", scopes=[ADMIN_SCOPE], auth_method="none")
+    else:
+        authorization = websocket.headers.get("authorization", "")
+        token = authorization[7:] if authorization.lower().startswith("bearer ") else websocket.query_params.get("access_token")
+        x_api_key = websocket.headers.get("x-api-key")
+        if token:
+            principal = await principal_from_token(token)
+        elif x_api_key:
+            principal = await principal_from_api_key(x_api_key)
+        else:
+            principal = None
+
+    if not principal or not principal.has_scopes(scopes):
+        return None
+    return principal
+
+async def require_totp(request: Request, x_totp_code: Optional[str] = Header(None)):
+    """Require a TOTP code from enrolled callers on high-privilege routes.
+
+    Must run after authentication. Callers without an enrollment pass unless
+    TOTP_REQUIRED is set.
+    """
+    principal: Optional[Principal] = getattr(request.state, "principal", None)
+    if principal is None:
+        raise HTTPException(status_code=status.HTTP_401_UNAUTHORIZED, detail="Not authenticated")
+    if principal.auth_method == "none":
+        return
+
+    if not await totp_service.is_enrolled(principal.subject):
+        if env.TOTP_REQUIRED:
+            raise HTTPException(
+                status_code=status.HTTP_403_FORBIDDEN,
+                detail="Two-factor enrollment is required for this operation"
+            )
+        return
+
+    if not x_totp_code or not await totp_service.verify(principal.subject, x_totp_code):
+        raise HTTPException(
+            status_code=status.HTTP_403_FORBIDDEN,
+            detail="A valid X-TOTP-Code header is required for this operation"
+        )
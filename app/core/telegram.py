@@ -1,6 +1,5 @@
 from telegram import Bot, Update, Message
 from telegram.ext import Application, CommandHandler, CallbackQueryHandler
-from telegram.constants import ParseMode
 from loguru import logger
 from typing import Optional
 import asyncio
@@ -12,14 +11,20 @@ from app.services.news_service import news_service
 from app.services.alert_service import alert_service
 from app.services.notification_service import notification_service
 from app.core.db import db
+from app.core.metrics import ALERT_CHECK_DURATION, ALERTS_TRIGGERED, instrument_command
+from app.core.tracing import trace_command, tracer
 from app.services.coin_service import coin_service
+from app.services.portfolio_service import portfolio_service
+from app.services.gas_service import gas_service
+from app.services.event_bus import event_bus
 
 # Import handlers
 from app.core.handlers.start_handlers import start_command, help_command
-from app.core.handlers.price_handlers import price_command, coins_command, price_history_command
+from app.core.handlers.price_handlers import price_command, coins_command, price_history_command, gas_command
 from app.core.handlers.news_handlers import news_command, headlines_command
 from app.core.handlers.alert_handlers import set_alert_command, list_alerts_command, delete_alert_command
 from app.core.handlers.callback_handlers import button_callback
+from app.core.handlers.portfolio_handlers import portfolio_command, add_position_command, remove_position_command
 
 class TelegramBot:
     _instance: Optional['TelegramBot'] = None
@@ -39,6 +44,7 @@ class TelegramBot:
             self._initialized = True
             self._polling_task: Optional[asyncio.Task] = None
             self._alert_task: Optional[asyncio.Task] = None
+            self._stopping = asyncio.Event()
 
     async def initialize(self):
         """Initialize the bot and prepare for polling"""
@@ -77,6 +83,14 @@ class TelegramBot:
             logger.error(f"Failed to initialize Telegram bot: {e}")
             raise
 
+    def is_running(self) -> bool:
+        """Whether the bot is connected and polling for updates"""
+        return bool(self.application and self.application.updater and self.application.updater.running)
+
+    def is_alert_checker_running(self) -> bool:
+        """Whether the background alert checker task is alive"""
+        return bool(self._alert_task and not self._alert_task.done())
+
     async def _start_polling(self):
         """Run polling in an infinite loop"""
         try:
@@ -118,16 +132,20 @@ class TelegramBot:
             ("price", "Get current price for a coin", lambda u, c: price_command(u, c, price_service)),
             ("coins", "List supported coins", lambda u, c: coins_command(u, c, coin_service)),
             ("history", "View price history", lambda u, c: price_history_command(u, c, price_service)),
+            ("gas", "Current network gas fees", lambda u, c: gas_command(u, c, gas_service)),
             ("headlines", "Get top 5 crypto headlines", lambda u, c: headlines_command(u, c, news_service)),
             ("news", "Get latest crypto news with images and descriptions", lambda u, c: news_command(u, c, news_service)),
             ("setalert", "Set price alert", lambda u, c: set_alert_command(u, c, alert_service, price_service)),
             ("alerts", "View your active alerts", lambda u, c: list_alerts_command(u, c, alert_service, price_service)),
             ("delalert", "Delete an alert", lambda u, c: delete_alert_command(u, c, alert_service)),
+            ("portfolio", "View your portfolio", lambda u, c: portfolio_command(u, c, portfolio_service)),
+            ("add", "Add a portfolio position", lambda u, c: add_position_command(u, c, portfolio_service)),
+            ("remove", "Remove a portfolio position", lambda u, c: remove_position_command(u, c, portfolio_service)),
         ]
 
         # Register command handlers
         for command, _, handler in commands:
-            self.application.add_handler(CommandHandler(command, handler))
+            self.application.add_handler(CommandHandler(command, trace_command(command, instrument_command(command, handler))))
         
         # Register callback query handler
         self.application.add_handler(CallbackQueryHandler(lambda u, c: button_callback(u, c, price_service, coin_service)))
@@ -139,11 +157,20 @@ class TelegramBot:
 
         logger.info("Command handlers registered")
 
+    async def _sleep_unless_stopping(self, seconds: float):
+        """Sleep, waking early when shutdown begins"""
+        try:
+            await asyncio.wait_for(self._stopping.wait(), timeout=seconds)
+        except asyncio.TimeoutError:
+            pass
+
     async def _check_alerts_loop(self):
         """Periodically check alerts in background with improved notification formatting"""
-        while True:
+        while not self._stopping.is_set():
             try:
-                triggered_alerts = await alert_service.check_alerts()
+                with tracer.start_as_current_span("alerts.check"), ALERT_CHECK_DURATION.time():
+                    triggered_alerts = await alert_service.check_alerts()
+                ALERTS_TRIGGERED.inc(len(triggered_alerts))
                 
                 # Process notifications in parallel
                 tasks = []
@@ -151,61 +178,43 @@ class TelegramBot:
                     symbol = alert['symbol'].upper()
                     target_price = alert['target_price']
                     current_price = alert['current_price']
-                    price_diff = ((current_price - target_price) / target_price * 100)
                     condition = alert['condition']
+                    event_bus.publish("alerts.triggered", {
+                        "user_id": alert['user_id'],
+                        "symbol": symbol,
+                        "condition": condition,
+                        "target_price": target_price,
+                        "current_price": current_price,
+                    })
                     
                     # Get additional price data if available
                     price_data_info = alert.get('price_data', {})
-                    high_24h = price_data_info.get('high_24h', 0)
-                    low_24h = price_data_info.get('low_24h', 0)
-                    change_24h = price_data_info.get('change_24h', 0)
-                    
-                    # Determine direction emoji and status
-                    direction_emoji = '📈' if condition == 'above' else '📉'
-                    
-                    # Format the alert message with markdown
-                    message = (
-                        f"{direction_emoji} *PRICE ALERT* {direction_emoji}\n\n"
-                        f"• *{symbol}* is now *{condition.upper()}* your target price!\n\n"
-                        f"🎯 *Target:* ${target_price:,.2f} ({condition})\n"
-                        f"💰 *Current:* ${current_price:,.2f} ({price_diff:+.2f}%)"
+                    data = alert_service.notification_data(
+                        symbol, condition, target_price, current_price,
+                        high_24h=price_data_info.get('high_24h', 0),
+                        low_24h=price_data_info.get('low_24h', 0),
+                        change_24h=price_data_info.get('change_24h', 0),
                     )
                     
-                    # Add 24h stats if available
-                    if high_24h or low_24h:
-                        message += (
-                            f"\n\n📊 *24h Stats:*\n"
-                            f"• High: ${high_24h:,.2f}\n"
-                            f"• Low: ${low_24h:,.2f}\n"
-                            f"• Change: {change_24h:+.2f}%"
-                        )
-                    
-                    # Add action buttons
-                    message += "\n\n🔔 Use /alerts to manage your alerts"
-                    
-                    # Send the notification
-                    tasks.append(
-                        notification_service.send_message(
-                            chat_id=alert['user_id'],
-                            text=message,
-                            parse_mode=ParseMode.MARKDOWN
-                        )
-                    )
+                    # Deliver over the channels the user chose for alerts
+                    tasks.append(notification_service.notify(alert['user_id'], "alerts.triggered", data))
                 
                 # Send all notifications in parallel
                 if tasks:
                     await asyncio.gather(*tasks, return_exceptions=True)
                 
                 # Wait for 1 minute before next check
-                await asyncio.sleep(60)
+                await self._sleep_unless_stopping(60)
             
             except Exception as e:
                 logger.error(f"Error in alert checker loop: {e}")
-                await asyncio.sleep(60)  # Wait before retrying
+                await self._sleep_unless_stopping(60)  # Wait before retrying
 
     async def shutdown(self):
         """Shutdown the bot and clean up resources"""
         try:
+            self._stopping.set()
+
             if self._polling_task:
                 self._polling_task.cancel()
                 try:
@@ -214,6 +223,10 @@ class TelegramBot:
                     pass
 
             if self._alert_task:
+                # Let an in-progress alert run finish sending its notifications
+                _, pending = await asyncio.wait({self._alert_task}, timeout=env.SHUTDOWN_GRACE_PERIOD)
+                if pending:
+                    logger.warning("Alert checker did not stop within the grace period, cancelling")
                 self._alert_task.cancel()
                 try:
                     await self._alert_task
@@ -0,0 +1,52 @@
+from fastapi import FastAPI
+from opentelemetry import trace
+from functools import wraps
+from loguru import logger
+
+from env import env
+
+# No-op until setup_tracing installs an SDK tracer provider
+tracer = trace.get_tracer("wavedex-bot")
+
+def setup_tracing(app: FastAPI):
+    """Export spans via OTLP and instrument inbound and outbound HTTP.
+
+    The exporter reads the standard OTEL_EXPORTER_OTLP_* variables. Incoming
+    W3C traceparent headers are honoured by the FastAPI instrumentation.
+    """
+    if not env.OTEL_ENABLED:
+        return
+
+    from opentelemetry.exporter.otlp.proto.http.trace_exporter import OTLPSpanExporter
+    from opentelemetry.instrumentation.aiohttp_client import AioHttpClientInstrumentor
+    from opentelemetry.instrumentation.fastapi import FastAPIInstrumentor
+    from opentelemetry.instrumentation.httpx import HTTPXClientInstrumentor
+    from opentelemetry.sdk.resources import Resource
+    from opentelemetry.sdk.trace import TracerProvider
+    from opentelemetry.sdk.trace.export import BatchSpanProcessor
+
+    provider = TracerProvider(resource=Resource.create({"service.name": env.OTEL_SERVICE_NAME}))
+    provider.add_span_processor(BatchSpanProcessor(OTLPSpanExporter()))
+    trace.set_tracer_provider(provider)
+
+    FastAPIInstrumentor.instrument_app(app, excluded_urls="healthz,readyz,metrics")
+    HTTPXClientInstrumentor().instrument()
+    AioHttpClientInstrumentor().instrument()
+    logger.info(f"Tracing enabled for service {env.OTEL_SERVICE_NAME}")
+
+def shutdown_tracing():
+    """Flush buffered spans"""
+    provider = trace.get_tracer_provider()
+    if hasattr(provider, "shutdown"):
+        provider.shutdown()
+
+def trace_command(command: str, handler):
+    """Wrap a Telegram command handler in a span"""
+    @wraps(handler)
+    async def wrapper(update, context):
+        with tracer.start_as_current_span(f"bot.command {command}") as span:
+            span.set_attribute("bot.command", command)
+            if update.effective_user:
+                span.set_attribute("bot.user_id", update.effective_user.id)
+            return await handler(update, context)
+    return wrapper
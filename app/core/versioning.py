@@ -0,0 +1,18 @@
+from fastapi import Request, Response
+
+API_PREFIX = "/api/v1"
+# Unversioned prefix kept as a deprecated alias of the current version
+LEGACY_API_PREFIX = "/api"
+
+def strip_api_prefix(path: str) -> str:
+    """Route path relative to whichever API prefix it is mounted under"""
+    for prefix in (API_PREFIX, LEGACY_API_PREFIX):
+        if path == prefix or path.startswith(prefix + "/"):
+            return path[len(prefix):] or "/"
+    return path
+
+async def legacy_api_notice(request: Request, response: Response):
+    """Flag responses served via the unversioned alias as deprecated"""
+    successor = API_PREFIX + strip_api_prefix(request.url.path)
+    response.headers["Deprecation"] = "true"
+    response.headers["Link"] = f'<{successor}>; rel="successor-version"'
@@ -1,5 +1,5 @@
 from pydantic import BaseModel, Field
-from typing import Optional, List, Dict
+from typing import Any, Optional, List, Dict
 from datetime import datetime
 from enum import Enum
 
@@ -106,4 +106,246 @@ class MarketData(BaseModel):
     volume_24h: float
     high_24h: float
     low_24h: float
-    last_updated: datetime 
\ No newline at end of file
+    last_updated: datetime 
+
+class ApiKeyBase(BaseModel):
+    label: str
+    scopes: List[str] = Field(default_factory=list)
+
+class ApiKeyCreate(ApiKeyBase):
+    pass
+
+class ApiKey(ApiKeyBase):
+    id: str
+    prefix: str
+    last_used_at: Optional[datetime] = None
+    revoked_at: Optional[datetime] = None
+    created_at: datetime
+
+    class Config:
+        from_attributes = True
+
+class ApiKeyCreated(ApiKey):
+    key: str
+
+class TokenRequest(BaseModel):
+    api_key: str
+    scopes: Optional[List[str]] = None
+
+class TokenResponse(BaseModel):
+    access_token: str
+    token_type: str = "bearer"
+    expires_in: int
+    scope: str
+    refresh_token: Optional[str] = None
+
+class RefreshTokenRequest(BaseModel):
+    refresh_token: str
+
+class TokenRevocationRequest(BaseModel):
+    token: str
+    token_type_hint: Optional[str] = None
+
+class TokenIntrospectionRequest(TokenRevocationRequest):
+    pass
+
+class TokenIntrospection(BaseModel):
+    active: bool
+    scope: Optional[str] = None
+    sub: Optional[str] = None
+    token_type: Optional[str] = None
+    exp: Optional[int] = None
+    iat: Optional[int] = None
+    iss: Optional[str] = None
+    jti: Optional[str] = None
+
+class TotpSetup(BaseModel):
+    secret: str
+    provisioning_uri: str
+
+class TotpVerifyRequest(BaseModel):
+    code: str
+
+class MarketPrice(BaseModel):
+    symbol: str
+    price_usd: float
+    change_24h: Optional[float] = None
+    volume_24h: Optional[float] = None
+    market_cap: Optional[float] = None
+    high_24h: Optional[float] = None
+    low_24h: Optional[float] = None
+    source: str
+    fetched_at: datetime
+
+class PricesResponse(BaseModel):
+    prices: Dict[str, MarketPrice]
+    missing: List[str] = Field(default_factory=list)
+
+class Candle(BaseModel):
+    open_time: datetime
+    open: float
+    high: float
+    low: float
+    close: float
+    volume: float
+
+class CandlesResponse(BaseModel):
+    symbol: str
+    interval: str
+    candles: List[Candle]
+
+class PriceAlertCreate(BaseModel):
+    user_id: int  # Telegram chat that receives the notification
+    symbol: str
+    condition: AlertCondition
+    target_price: float = Field(gt=0)
+    cooldown_seconds: Optional[int] = Field(None, ge=60)
+
+class PriceAlertInfo(BaseModel):
+    id: str
+    user_id: int
+    symbol: str
+    condition: AlertCondition
+    target_price: float
+    current_price: Optional[float] = None
+    cooldown_seconds: Optional[int] = None
+    last_triggered_at: Optional[int] = None
+    created_at: int
+
+class AlertHistoryEntry(BaseModel):
+    alert_id: str
+    user_id: str
+    symbol: str
+    condition: AlertCondition
+    target_price: float
+    triggered_price: float
+    triggered_at: datetime
+
+class PositionCreate(BaseModel):
+    symbol: str
+    quantity: float = Field(gt=0)
+    price: float = Field(ge=0)
+
+class WalletCreate(BaseModel):
+    chain: str = "ethereum"
+    address: str
+    label: Optional[str] = None
+
+class WalletInfo(WalletCreate):
+    id: str
+
+class Holding(BaseModel):
+    symbol: str
+    quantity: float
+    price_usd: Optional[float] = None
+    value_usd: Optional[float] = None
+    allocation_pct: Optional[float] = None
+    cost_basis_usd: Optional[float] = None
+    source: str  # "position" or "wallet:<chain>:<address>"
+
+class PortfolioValuation(BaseModel):
+    total_value_usd: float
+    cost_basis_usd: float
+    unrealized_pnl_usd: float
+    holdings: List[Holding]
+    unpriced: List[str] = Field(default_factory=list)
+
+class PortfolioSnapshotInfo(BaseModel):
+    total_value_usd: float
+    holdings: List[Dict]
+    taken_at: datetime
+
+class SwapQuote(BaseModel):
+    aggregator: str
+    chain: str
+    sell_token: str
+    buy_token: str
+    sell_amount: str  # Base units, as strings to keep full precision
+    buy_amount: str
+    price_impact_pct: Optional[float] = None
+    estimated_gas: Optional[int] = None
+    route: List[str] = Field(default_factory=list)
+
+class SwapQuotesResponse(BaseModel):
+    best: Optional[SwapQuote] = None
+    quotes: List[SwapQuote]
+    errors: Dict[str, str] = Field(default_factory=dict)
+class GasTier(BaseModel):
+    max_fee_gwei: float
+    priority_fee_gwei: Optional[float] = None  # Only for EIP-1559 chains
+
+class GasEstimate(BaseModel):
+    chain: str
+    eip1559: bool
+    base_fee_gwei: Optional[float] = None
+    slow: GasTier
+    standard: GasTier
+    fast: GasTier
+    block_number: Optional[int] = None
+    updated_at: datetime
+class ArbitrageOpportunity(BaseModel):
+    symbol: str
+    buy_source: str
+    buy_price: float
+    sell_source: str
+    sell_price: float
+    gross_spread_pct: float
+    net_spread_pct: float  # After ARBITRAGE_FEE_PCT on both legs
+    detected_at: datetime
+class RpcEndpointInfo(BaseModel):
+    url: str
+    healthy: bool
+    latency_ms: Optional[float] = None
+    last_error: Optional[str] = None
+    checked_at: Optional[datetime] = None
+
+class ChainInfo(BaseModel):
+    name: str
+    kind: str
+    chain_id: Optional[int] = None
+    native_symbol: str
+    decimals: int
+    endpoints: List[RpcEndpointInfo]
+
+class ChainUpdate(BaseModel):
+    """Runtime chain settings; omitted fields keep their current values"""
+    rpc_urls: List[str] = Field(..., min_length=1)
+    kind: Optional[str] = Field(None, pattern="^(evm|solana)$")
+    chain_id: Optional[int] = None
+    native_symbol: Optional[str] = None
+    decimals: Optional[int] = Field(None, ge=0, le=36)
+class NotificationSettings(BaseModel):
+    """Per-user routing: destinations by channel and channels by event type ("*" for any)"""
+    destinations: Dict[str, str] = Field(default_factory=dict)
+    routes: Dict[str, List[str]] = Field(default_factory=dict)
+class MaintenanceUpdate(BaseModel):
+    enabled: bool
+    retry_after: int = Field(60, ge=1, le=86400)  # Seconds, sent as Retry-After
+    reason: Optional[str] = Field(None, max_length=200)
+class CapturedRequestInfo(BaseModel):
+    id: str
+    request_id: Optional[str] = None
+    method: str
+    path: str
+    query: str
+    subject: Optional[str] = None
+    request_headers: Dict[str, str]
+    request_body: Optional[str] = None
+    body_truncated: bool = False
+    body_redacted: bool = False
+    body_summarized: bool = False
+    status_code: int
+    response_headers: Dict[str, str]
+    response_body: Optional[str] = None
+    duration_ms: float
+    created_at: datetime
+class KvEntry(BaseModel):
+    namespace: str
+    key: str
+    value: Any
+    revision: int
+    expires_at: Optional[datetime] = None
+    updated_at: datetime
+class KvPut(BaseModel):
+    value: Any
+    ttl: Optional[int] = Field(None, ge=1)  # Seconds until the entry expires
\ No newline at end of file
@@ -193,23 +193,39 @@ class AlertService:
         except Exception as e:
             logger.error(f"Error processing alert {alert.id}: {e}")
 
+    @staticmethod
+    def notification_data(symbol: str, condition: str, target_price: float, current_price: float,
+                          high_24h: float = 0, low_24h: float = 0, change_24h: float = 0) -> Dict[str, str]:
+        """Template values for an alerts.triggered notification"""
+        stats = ""
+        if high_24h or low_24h:
+            stats = (
+                f"\n\n📊 24h Stats:\n"
+                f"• High: ${high_24h:,.2f}\n"
+                f"• Low: ${low_24h:,.2f}\n"
+                f"• Change: {change_24h:+.2f}%"
+            )
+        return {
+            "symbol": symbol.upper(),
+            "condition": condition,
+            "condition_upper": condition.upper(),
+            "direction_emoji": "📈" if condition == "above" else "📉",
+            "target_price": f"{target_price:,.2f}",
+            "current_price": f"{current_price:,.2f}",
+            "price_diff": f"{(current_price - target_price) / target_price * 100:+.2f}",
+            "stats": stats,
+        }
+
     async def _trigger_alert(self, alert: Alert, market_data: MarketData):
         """Trigger alert notification and update alert status"""
         try:
-            # Format message
-            message = (
-                f"🚨 Price Alert for {alert.symbol}!\n\n"
-                f"Current Price: ${market_data.price:,.2f}\n"
-                f"Alert {alert.condition}: ${alert.price_threshold:,.2f}\n"
-                f"24h Change: {market_data.price_change_24h:+.2f}%\n\n"
-                f"Market Cap: ${market_data.market_cap:,.0f}\n"
-                f"24h Volume: ${market_data.volume_24h:,.0f}"
-            )
-
-            # Send notification
-            await notification_service.send_message(
-                chat_id=alert.user.telegramId,
-                text=message
+            await notification_service.notify(
+                alert.user.telegramId,
+                "alerts.triggered",
+                self.notification_data(
+                    alert.symbol, alert.condition, alert.price_threshold, market_data.price,
+                    change_24h=market_data.price_change_24h or 0,
+                )
             )
 
             # Deactivate alert
@@ -311,10 +327,18 @@ class AlertService:
                     
                     if alert_data:
                         alert = alert_data
+                        if self._in_cooldown(alert):
+                            continue
                         if await self._check_alert(alert):
                             triggered_alerts.append(alert)
-                            # Delete triggered alert
-                            await self.delete_alert(user_id, alert_id)
+                            await self._record_trigger(alert)
+                            if alert.get("cooldown"):
+                                # Repeating alert: re-arm once the cooldown has passed
+                                alert["last_triggered_at"] = int(time.time())
+                                await self.cache.set_key(alert_key, alert)
+                            else:
+                                # Delete triggered alert
+                                await self.delete_alert(user_id, alert_id)
 
             return triggered_alerts
 
@@ -322,6 +346,50 @@ class AlertService:
             logger.error(f"Error checking alerts: {e}")
             return []
 
+    @staticmethod
+    def _in_cooldown(alert: Dict[str, Any]) -> bool:
+        """Whether a repeating alert fired too recently to fire again"""
+        last_triggered = alert.get("last_triggered_at")
+        cooldown = alert.get("cooldown")
+        return bool(cooldown and last_triggered and time.time() < last_triggered + cooldown)
+
+    async def _record_trigger(self, alert: Dict[str, Any]):
+        """Append a triggered alert to the history table"""
+        try:
+            await db.prisma.alertevent.create(
+                data={
+                    "alertId": alert["id"],
+                    "userId": str(alert["user_id"]),
+                    "symbol": alert["symbol"],
+                    "condition": alert["condition"],
+                    "targetPrice": float(alert["target_price"]),
+                    "triggeredPrice": float(alert["current_price"]),
+                }
+            )
+        except Exception as e:
+            logger.error(f"Error recording alert trigger for {alert.get('id')}: {e}")
+
+    async def get_alert(self, alert_id: str) -> Optional[Dict[str, Any]]:
+        """Get a single alert by ID"""
+        return await self.cache.get_key(f"{self._alert_key_prefix}{alert_id}")
+
+    async def get_history(self, user_id: Optional[int] = None, symbol: Optional[str] = None, limit: int = 50) -> List[Any]:
+        """Most recent alert triggers, optionally filtered by user and symbol"""
+        try:
+            where: Dict[str, Any] = {}
+            if user_id is not None:
+                where["userId"] = str(user_id)
+            if symbol:
+                where["symbol"] = symbol.upper()
+            return await db.prisma.alertevent.find_many(
+                where=where,
+                order={"triggeredAt": "desc"},
+                take=limit
+            )
+        except Exception as e:
+            logger.error(f"Error fetching alert history: {e}")
+            return []
+
     async def _check_alert(self, alert: Dict[str, Any]) -> bool:
         """Check if an alert should be triggered"""
         try:
@@ -377,8 +445,12 @@ class AlertService:
             logger.error(f"Error checking alert {alert.get('id')}: {e}")
             return False
 
-    async def set_alert(self, user_id: int, symbol: str, target_price: float, condition: str) -> Dict[str, Any]:
-        """Set a price alert for a user"""
+    async def set_alert(self, user_id: int, symbol: str, target_price: float, condition: str, cooldown: Optional[int] = None) -> Dict[str, Any]:
+        """Set a price alert for a user.
+
+        Alerts fire once and are removed, unless a cooldown (seconds) is given,
+        in which case they re-arm that long after each trigger.
+        """
         logger.info(f"[set_alert] Starting alert creation for user {user_id}, symbol {symbol}, target {target_price}, condition {condition}")
         
         try:
@@ -472,7 +544,9 @@ class AlertService:
                 "condition": condition,
                 "current_price": current_price,
                 "created_at": int(time.time()),
-                "price_data": price_data
+                "price_data": price_data,
+                "cooldown": cooldown,
+                "last_triggered_at": None
             }
             logger.info(f"[set_alert] Created alert data: {alert_data}")
 
@@ -0,0 +1,140 @@
+from typing import List, Optional, Tuple
+from loguru import logger
+from datetime import datetime, timezone
+import hashlib
+import secrets
+
+from app.core.db import db
+from app.models.schemas import ApiKey
+from app.services.event_bus import event_bus
+
+class ApiKeyService:
+    _instance: Optional['ApiKeyService'] = None
+    _initialized: bool = False
+    KEY_PREFIX = "wdx_"
+    KEY_BYTES = 32  # Entropy of the random part of a key
+    DISPLAY_PREFIX_LENGTH = 8
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(ApiKeyService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._initialized = True
+
+    @staticmethod
+    def hash_key(raw_key: str) -> str:
+        """Hash a raw API key for storage and lookup"""
+        return hashlib.sha256(raw_key.encode()).hexdigest()
+
+    def _generate_key(self) -> str:
+        """Generate a new opaque API key"""
+        return f"{self.KEY_PREFIX}{secrets.token_urlsafe(self.KEY_BYTES)}"
+
+    def _to_schema(self, record) -> ApiKey:
+        """Convert a Prisma ApiKey record to the API schema"""
+        return ApiKey(
+            id=record.id,
+            label=record.label,
+            scopes=list(record.scopes or []),
+            prefix=record.prefix,
+            last_used_at=record.lastUsedAt,
+            revoked_at=record.revokedAt,
+            created_at=record.createdAt,
+        )
+
+    async def create_key(self, label: str, scopes: List[str]) -> Tuple[str, ApiKey]:
+        """Create a new API key and return the raw key with its stored record.
+
+        The raw key is only available here; only its hash is persisted.
+        """
+        try:
+            raw_key = self._generate_key()
+            record = await db.prisma.apikey.create(
+                data={
+                    "label": label,
+                    "prefix": raw_key[len(self.KEY_PREFIX):len(self.KEY_PREFIX) + self.DISPLAY_PREFIX_LENGTH],
+                    "keyHash": self.hash_key(raw_key),
+                    "scopes": scopes,
+                }
+            )
+            logger.info(f"API key created: {record.id} ({label})")
+            event_bus.publish("auth.key_created", {"key_id": record.id, "label": label, "scopes": scopes})
+            return raw_key, self._to_schema(record)
+        except Exception as e:
+            logger.error(f"Error creating API key: {e}")
+            raise
+
+    async def list_keys(self, include_revoked: bool = False) -> List[ApiKey]:
+        """List stored API keys"""
+        try:
+            where = {} if include_revoked else {"revokedAt": None}
+            records = await db.prisma.apikey.find_many(
+                where=where,
+                order={"createdAt": "desc"}
+            )
+            return [self._to_schema(record) for record in records]
+        except Exception as e:
+            logger.error(f"Error listing API keys: {e}")
+            raise
+
+    async def revoke_key(self, key_id: str) -> Optional[ApiKey]:
+        """Revoke an API key, returns None if it doesn't exist"""
+        try:
+            record = await db.prisma.apikey.find_unique(where={"id": key_id})
+            if not record:
+                return None
+            if record.revokedAt is None:
+                record = await db.prisma.apikey.update(
+                    where={"id": key_id},
+                    data={"revokedAt": datetime.now(timezone.utc)}
+                )
+                logger.info(f"API key revoked: {key_id}")
+                event_bus.publish("auth.key_revoked", {"key_id": key_id})
+            return self._to_schema(record)
+        except Exception as e:
+            logger.error(f"Error revoking API key {key_id}: {e}")
+            raise
+
+    async def is_active(self, key_id: str) -> bool:
+        """Check whether a key exists and has not been revoked"""
+        try:
+            record = await db.prisma.apikey.find_unique(where={"id": key_id})
+            return bool(record) and record.revokedAt is None
+        except Exception as e:
+            logger.error(f"Error checking API key {key_id}: {e}")
+            return False
+
+    async def fingerprint(self, key_id: str) -> Optional[str]:
+        """Stored hash of an active key, or None if it doesn't exist or was revoked"""
+        try:
+            record = await db.prisma.apikey.find_unique(where={"id": key_id})
+            return record.keyHash if record and record.revokedAt is None else None
+        except Exception as e:
+            logger.error(f"Error checking API key {key_id}: {e}")
+            return None
+
+    async def verify_key(self, raw_key: str) -> Optional[ApiKey]:
+        """Return the active key matching a raw API key, or None"""
+        if not raw_key or not raw_key.startswith(self.KEY_PREFIX):
+            return None
+        try:
+            record = await db.prisma.apikey.find_unique(
+                where={"keyHash": self.hash_key(raw_key)}
+            )
+            if not record or record.revokedAt is not None:
+                return None
+
+            record = await db.prisma.apikey.update(
+                where={"id": record.id},
+                data={"lastUsedAt": datetime.now(timezone.utc)}
+            )
+            return self._to_schema(record)
+        except Exception as e:
+            logger.error(f"Error verifying API key: {e}")
+            return None
+
+# Create singleton instance
+api_key_service = ApiKeyService()
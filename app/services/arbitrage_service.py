@@ -0,0 +1,137 @@
+from typing import Dict, List, Optional, Set, Tuple
+from collections import deque
+from datetime import datetime, timezone
+from loguru import logger
+import asyncio
+import itertools
+
+from env import env
+from app.models.schemas import ArbitrageOpportunity, MarketPrice
+from app.services.event_bus import event_bus
+from app.services.market_data_service import PROVIDERS, PriceProvider
+from app.services.notification_service import notification_service
+
+class ArbitrageService:
+    """Periodically compares each symbol's price across providers.
+
+    Spreads are reported net of ARBITRAGE_FEE_PCT per leg. An opportunity
+    is announced when it opens and again only after it has closed.
+    """
+    _instance: Optional['ArbitrageService'] = None
+    _initialized: bool = False
+    MAX_FINDINGS = 500
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(ArbitrageService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.providers: List[PriceProvider] = []
+            self.findings: deque = deque(maxlen=self.MAX_FINDINGS)
+            self._open: Set[Tuple[str, str, str]] = set()
+            self._running = False
+            self._task: Optional[asyncio.Task] = None
+            self._initialized = True
+
+    async def start(self):
+        """Start the scan loop if enabled"""
+        if self._running or not env.ARBITRAGE_ENABLED:
+            return
+        self.providers = [PROVIDERS[name]() for name in env.ARBITRAGE_PROVIDERS]
+        self._running = True
+        self._task = asyncio.create_task(self._scan_loop())
+        logger.info(f"Arbitrage scanner started for {', '.join(env.ARBITRAGE_SYMBOLS)}")
+
+    async def stop(self):
+        """Stop the scan loop and close provider clients"""
+        if not self._running:
+            return
+        self._running = False
+        if self._task:
+            self._task.cancel()
+            try:
+                await self._task
+            except asyncio.CancelledError:
+                pass
+        for provider in self.providers:
+            await provider.close()
+        logger.info("Arbitrage scanner stopped")
+
+    async def _fetch(self, provider: PriceProvider) -> Dict[str, MarketPrice]:
+        try:
+            return await provider.get_prices(env.ARBITRAGE_SYMBOLS)
+        except Exception as e:
+            logger.error(f"Arbitrage price fetch from {provider.name} failed: {e}")
+            return {}
+
+    def compare(self, quotes: List[MarketPrice]) -> List[ArbitrageOpportunity]:
+        """Price differences between every pair of sources for one symbol"""
+        opportunities = []
+        fees = 2 * env.ARBITRAGE_FEE_PCT
+        now = datetime.now(timezone.utc)
+        for a, b in itertools.combinations(quotes, 2):
+            low, high = (a, b) if a.price_usd <= b.price_usd else (b, a)
+            if low.price_usd <= 0:
+                continue
+            gross = (high.price_usd - low.price_usd) / low.price_usd * 100
+            opportunities.append(ArbitrageOpportunity(
+                symbol=low.symbol,
+                buy_source=low.source,
+                buy_price=low.price_usd,
+                sell_source=high.source,
+                sell_price=high.price_usd,
+                gross_spread_pct=round(gross, 4),
+                net_spread_pct=round(gross - fees, 4),
+                detected_at=now,
+            ))
+        return opportunities
+
+    async def scan(self) -> List[ArbitrageOpportunity]:
+        """Run one comparison pass, recording and announcing new opportunities"""
+        results = await asyncio.gather(*(self._fetch(provider) for provider in self.providers))
+        found = []
+        still_open = set()
+        for symbol in env.ARBITRAGE_SYMBOLS:
+            quotes = [prices[symbol] for prices in results if symbol in prices]
+            for opportunity in self.compare(quotes):
+                if opportunity.net_spread_pct < env.ARBITRAGE_MIN_SPREAD_PCT:
+                    continue
+                key = (opportunity.symbol, opportunity.buy_source, opportunity.sell_source)
+                still_open.add(key)
+                if key in self._open:
+                    continue
+                found.append(opportunity)
+                self.findings.appendleft(opportunity)
+                await self._announce(opportunity)
+        self._open = still_open
+        return found
+
+    async def _announce(self, opportunity: ArbitrageOpportunity):
+        event_bus.publish("arbitrage.opportunity", opportunity.model_dump(mode="json"))
+        data = {
+            **opportunity.model_dump(mode="json"),
+            "buy_price": f"{opportunity.buy_price:,.4f}",
+            "sell_price": f"{opportunity.sell_price:,.4f}",
+            "gross_spread_pct": f"{opportunity.gross_spread_pct:.2f}",
+            "net_spread_pct": f"{opportunity.net_spread_pct:.2f}",
+        }
+        chats = [("telegram", str(chat_id)) for chat_id in env.ARBITRAGE_ALERT_CHAT_IDS]
+        await notification_service.notify_operators("arbitrage.opportunity", data, extra=chats)
+
+    def recent(self, symbol: Optional[str] = None, limit: int = 50) -> List[ArbitrageOpportunity]:
+        """Recorded opportunities, newest first"""
+        findings = (f for f in self.findings if symbol is None or f.symbol == symbol.upper())
+        return list(itertools.islice(findings, limit))
+
+    async def _scan_loop(self):
+        while self._running:
+            try:
+                await self.scan()
+            except Exception as e:
+                logger.error(f"Error in arbitrage scan: {e}")
+            await asyncio.sleep(env.ARBITRAGE_SCAN_INTERVAL)
+
+# Create singleton instance
+arbitrage_service = ArbitrageService()
@@ -1,13 +1,166 @@
-from typing import Any, Dict, Optional, Union, List, Set
+from typing import Any, Dict, Optional, Union, List, Set, Tuple
+from collections import OrderedDict
+import asyncio
 import json
+import pickle
 import time
 from loguru import logger
 
+from env import env
+
+# Returned by backends for missing keys, so cached None/False values still count as hits
+_MISSING = object()
+
+class MemoryBackend:
+    """In-process LRU store; the least recently used keys are evicted past max_entries.
+
+    Only entries that are safe to lose are evictable. Keys without a TTL
+    (e.g. alert records) and replay-protection markers live in a separate,
+    unbounded store so cache churn can never drop them.
+    """
+    name = "memory"
+    # Keys whose loss would re-open a replay window, even though they expire
+    PINNED_PREFIXES = ("totp_used:", "hmac_nonce:")
+
+    def __init__(self, max_entries: int):
+        self.max_entries = max_entries
+        self._cache: 'OrderedDict[str, Tuple[Any, Optional[float]]]' = OrderedDict()  # key -> (value, expires at)
+        self._pinned: Dict[str, Tuple[Any, Optional[float]]] = {}  # never evicted, only expired
+        self._pinned_sweep_at = max_entries
+        self._sets: Dict[str, Set[str]] = {}  # set_name -> set of members
+        self._add_lock = asyncio.Lock()
+        self.evictions = 0
+
+    def _is_pinned(self, key: str, expiry: Optional[int]) -> bool:
+        return not expiry or key.startswith(self.PINNED_PREFIXES)
+
+    def _sweep_pinned(self):
+        """Drop expired pinned entries once the store has doubled since the last sweep"""
+        if len(self._pinned) < self._pinned_sweep_at:
+            return
+        now = time.time()
+        self._pinned = {k: item for k, item in self._pinned.items() if not item[1] or item[1] >= now}
+        self._pinned_sweep_at = max(self.max_entries, len(self._pinned) * 2)
+
+    async def get(self, key: str) -> Any:
+        store = self._pinned if key in self._pinned else self._cache
+        item = store.get(key)
+        if item is None:
+            return _MISSING
+        value, expires_at = item
+        if expires_at and expires_at < time.time():
+            del store[key]
+            return _MISSING
+        if store is self._cache:
+            self._cache.move_to_end(key)
+        return value
+
+    async def set(self, key: str, value: Any, expiry: Optional[int]):
+        item = (value, time.time() + expiry if expiry else None)
+        if self._is_pinned(key, expiry):
+            self._cache.pop(key, None)
+            self._pinned[key] = item
+            self._sweep_pinned()
+            return
+        self._pinned.pop(key, None)
+        self._cache[key] = item
+        self._cache.move_to_end(key)
+        while len(self._cache) > self.max_entries:
+            self._cache.popitem(last=False)
+            self.evictions += 1
+
+    async def add(self, key: str, value: Any, expiry: Optional[int]) -> bool:
+        async with self._add_lock:
+            if await self.get(key) is not _MISSING:
+                return False
+            await self.set(key, value, expiry)
+            return True
+
+    async def delete(self, key: str) -> bool:
+        removed = self._cache.pop(key, None) is not None
+        removed = (self._pinned.pop(key, None) is not None) or removed
+        return (self._sets.pop(key, None) is not None) or removed
+
+    async def keys(self, prefix: str = "") -> List[str]:
+        # Sets share the key space, as they do in Redis, so scans find them too
+        return [k for store in (self._cache, self._pinned, self._sets) for k in store.keys() if k.startswith(prefix)]
+
+    async def smembers(self, key: str) -> Set[str]:
+        return self._sets.get(key, set())
+
+    async def sadd(self, key: str, *members: str):
+        self._sets.setdefault(key, set()).update(members)
+
+    async def srem(self, key: str, *members: str):
+        if key in self._sets:
+            self._sets[key].difference_update(members)
+
+    async def size(self) -> int:
+        return len(self._cache) + len(self._pinned)
+
+    async def close(self):
+        self._cache.clear()
+        self._pinned.clear()
+        self._sets.clear()
+
+class RedisBackend:
+    """Shared store for multi-instance deployments.
+
+    Values are pickled so cached objects round-trip unchanged; only point
+    REDIS_URL at a Redis instance this service alone trusts.
+    """
+    name = "redis"
+
+    def __init__(self, url: str, namespace: str):
+        import redis.asyncio as redis
+        self._redis = redis.from_url(url)
+        self._namespace = namespace
+
+    def _key(self, key: str) -> str:
+        return f"{self._namespace}{key}"
+
+    async def get(self, key: str) -> Any:
+        raw = await self._redis.get(self._key(key))
+        return _MISSING if raw is None else pickle.loads(raw)
+
+    async def set(self, key: str, value: Any, expiry: Optional[int]):
+        await self._redis.set(self._key(key), pickle.dumps(value), ex=expiry or None)
+
+    async def add(self, key: str, value: Any, expiry: Optional[int]) -> bool:
+        return bool(await self._redis.set(self._key(key), pickle.dumps(value), ex=expiry or None, nx=True))
+
+    async def delete(self, key: str) -> bool:
+        return await self._redis.delete(self._key(key)) > 0
+
+    async def keys(self, prefix: str = "") -> List[str]:
+        start = len(self._namespace)
+        return [k.decode()[start:] async for k in self._redis.scan_iter(match=f"{self._key(prefix)}*", count=500)]
+
+    async def smembers(self, key: str) -> Set[str]:
+        return {m.decode() for m in await self._redis.smembers(self._key(key))}
+
+    async def sadd(self, key: str, *members: str):
+        if members:
+            await self._redis.sadd(self._key(key), *members)
+
+    async def srem(self, key: str, *members: str):
+        if members:
+            await self._redis.srem(self._key(key), *members)
+
+    async def size(self) -> int:
+        return len(await self.keys())
+
+    async def close(self):
+        await self._redis.aclose()
+
 class CacheService:
+    """Key/value cache with TTLs, backed by an in-process LRU or Redis (REDIS_URL).
+
+    Hits and misses are counted per namespace, the part of a key before its
+    first ":".
+    """
     _instance: Optional['CacheService'] = None
     _initialized: bool = False
-    _cache: Dict[str, Dict[str, Any]]  # key -> {'value': Any, 'expiry': float}
-    _sets: Dict[str, Set[str]]  # set_name -> set of members
 
     def __new__(cls):
         if cls._instance is None:
@@ -16,51 +169,74 @@ class CacheService:
 
     def __init__(self):
         if not self._initialized:
-            self._cache = {}
-            self._sets = {}
+            if env.REDIS_URL:
+                self.backend = RedisBackend(env.REDIS_URL, env.REDIS_KEY_PREFIX)
+            else:
+                self.backend = MemoryBackend(env.CACHE_MAX_ENTRIES)
+            self._stats: Dict[str, Dict[str, int]] = {}
             self._initialized = True
 
+    @staticmethod
+    def _namespace(key: str) -> str:
+        return key.partition(":")[0] if ":" in key else "default"
+
+    def _count(self, key: str, outcome: str):
+        stats = self._stats.setdefault(self._namespace(key), {"hits": 0, "misses": 0, "sets": 0})
+        stats[outcome] += 1
+
     async def set_key(self, key: str, value: Any, expiry: Optional[int] = None):
         """Set key with optional expiry (in seconds)"""
         try:
-            self._cache[key] = {
-                'value': value,
-                'expiry': time.time() + expiry if expiry else None
-            }
+            await self.backend.set(key, value, expiry)
+            self._count(key, "sets")
         except Exception as e:
             logger.error(f"Error setting cache key {key}: {e}")
             raise
 
+    async def add_key(self, key: str, value: Any, expiry: Optional[int] = None) -> bool:
+        """Set key only if it doesn't exist yet, atomically; returns whether it was set"""
+        try:
+            added = await self.backend.add(key, value, expiry)
+            if added:
+                self._count(key, "sets")
+            return added
+        except Exception as e:
+            logger.error(f"Error adding cache key {key}: {e}")
+            raise
+
     async def get_key(self, key: str) -> Optional[Any]:
         """Get value by key, returns None if key doesn't exist or is expired"""
         try:
-            item = self._cache.get(key)
-            if item is None:
-                return None
-                
-            # Check if item is expired
-            if item['expiry'] and item['expiry'] < time.time():
-                del self._cache[key]
-                return None
-                
-            return item['value']
+            value = await self.backend.get(key)
         except Exception as e:
             logger.error(f"Error getting cache key {key}: {e}")
             raise
+        if value is _MISSING:
+            self._count(key, "misses")
+            return None
+        self._count(key, "hits")
+        return value
 
-    async def delete_key(self, key: str):
-        """Delete key"""
+    async def delete_key(self, key: str) -> bool:
+        """Delete key, returns whether it existed"""
         try:
-            if key in self._cache:
-                del self._cache[key]
+            return await self.backend.delete(key)
         except Exception as e:
             logger.error(f"Error deleting cache key {key}: {e}")
             raise
 
+    async def delete_prefix(self, prefix: str) -> int:
+        """Delete every key starting with prefix, returns how many were removed"""
+        removed = 0
+        for key in await self.backend.keys(prefix):
+            removed += await self.delete_key(key)
+        logger.info(f"Invalidated {removed} cache keys with prefix {prefix!r}")
+        return removed
+
     async def smembers(self, key: str) -> Set[str]:
         """Get all members of a set"""
         try:
-            return self._sets.get(key, set())
+            return await self.backend.smembers(key)
         except Exception as e:
             logger.error(f"Error getting set members for {key}: {e}")
             raise
@@ -68,9 +244,7 @@ class CacheService:
     async def sadd(self, key: str, *members: str):
         """Add members to a set"""
         try:
-            if key not in self._sets:
-                self._sets[key] = set()
-            self._sets[key].update(members)
+            await self.backend.sadd(key, *members)
         except Exception as e:
             logger.error(f"Error adding members to set {key}: {e}")
             raise
@@ -78,8 +252,7 @@ class CacheService:
     async def srem(self, key: str, *members: str):
         """Remove members from a set"""
         try:
-            if key in self._sets:
-                self._sets[key].difference_update(members)
+            await self.backend.srem(key, *members)
         except Exception as e:
             logger.error(f"Error removing members from set {key}: {e}")
             raise
@@ -89,19 +262,30 @@ class CacheService:
         try:
             # Simple pattern matching (only supports * at the end for now)
             if pattern.endswith('*'):
-                prefix = pattern[:-1]
-                return [k for k in self._cache.keys() if k.startswith(prefix)]
-            elif pattern in self._cache:
-                return [pattern]
-            return []
+                return await self.backend.keys(pattern[:-1])
+            return [k for k in await self.backend.keys(pattern) if k == pattern]
         except Exception as e:
             logger.error(f"Error scanning keys with pattern {pattern}: {e}")
             raise
 
+    async def stats(self) -> Dict[str, Any]:
+        """Backend, size and hit rates overall and per namespace"""
+        hits = sum(s["hits"] for s in self._stats.values())
+        misses = sum(s["misses"] for s in self._stats.values())
+        return {
+            "backend": self.backend.name,
+            "entries": await self.backend.size(),
+            "max_entries": getattr(self.backend, "max_entries", None),
+            "evictions": getattr(self.backend, "evictions", None),
+            "hits": hits,
+            "misses": misses,
+            "hit_rate": round(hits / (hits + misses), 4) if hits + misses else None,
+            "namespaces": self._stats,
+        }
+
     async def close(self):
         """Clean up resources"""
-        self._cache.clear()
-        self._sets.clear()
+        await self.backend.close()
 
 # For backward compatibility with existing code
 class RedisService(CacheService):
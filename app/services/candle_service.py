@@ -0,0 +1,150 @@
+from typing import Dict, List, Optional, Tuple
+from datetime import datetime, timedelta, timezone
+from loguru import logger
+
+from app.core.db import db
+from app.models.schemas import Candle
+from app.services.market_data_service import BinanceProvider
+
+# Supported intervals and their length in seconds
+INTERVALS: Dict[str, int] = {
+    "1m": 60,
+    "5m": 300,
+    "15m": 900,
+    "1h": 3600,
+    "4h": 14400,
+    "1d": 86400,
+}
+
+class CandleService:
+    """OHLCV candles stored in the database, back-filled from the exchange on demand"""
+    _instance: Optional['CandleService'] = None
+    _initialized: bool = False
+    MAX_CANDLES = 1000  # Per request, matching the exchange page size
+    SOURCE = "binance"
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(CandleService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.exchange = BinanceProvider()
+            self._initialized = True
+
+    async def close(self):
+        """Close the exchange client"""
+        await self.exchange.close()
+
+    @staticmethod
+    def align(moment: datetime, step: int) -> datetime:
+        """Round a time down to the start of its candle"""
+        seconds = int(moment.timestamp())
+        return datetime.fromtimestamp(seconds - seconds % step, tz=timezone.utc)
+
+    def _to_schema(self, record) -> Candle:
+        return Candle(
+            open_time=record.openTime,
+            open=record.open,
+            high=record.high,
+            low=record.low,
+            close=record.close,
+            volume=record.volume,
+        )
+
+    @staticmethod
+    def _gaps(expected: List[datetime], stored: set, step: int) -> List[Tuple[datetime, datetime]]:
+        """Group missing open times into contiguous (first, last) ranges"""
+        gaps: List[Tuple[datetime, datetime]] = []
+        for open_time in expected:
+            if open_time in stored:
+                continue
+            if gaps and open_time - gaps[-1][1] == timedelta(seconds=step):
+                gaps[-1] = (gaps[-1][0], open_time)
+            else:
+                gaps.append((open_time, open_time))
+        return gaps
+
+    async def _backfill(self, symbol: str, interval: str, first: datetime, last: datetime, step: int) -> List[Candle]:
+        """Fetch candles for a gap and store the ones that have closed.
+
+        Returns the still-open candle, if the gap includes it, since it is
+        never persisted.
+        """
+        closed_before = datetime.now(timezone.utc) - timedelta(seconds=step)
+        rows = await self.exchange.get_klines(
+            symbol,
+            interval,
+            int(first.timestamp() * 1000),
+            int(last.timestamp() * 1000),
+            limit=self.MAX_CANDLES,
+        )
+        data, live = [], []
+        for row in rows:
+            candle = Candle(
+                open_time=datetime.fromtimestamp(row[0] / 1000, tz=timezone.utc),
+                open=float(row[1]),
+                high=float(row[2]),
+                low=float(row[3]),
+                close=float(row[4]),
+                volume=float(row[5]),
+            )
+            if candle.open_time > closed_before:
+                live.append(candle)
+                continue
+            data.append({
+                "symbol": symbol,
+                "interval": interval,
+                "openTime": candle.open_time,
+                "open": candle.open,
+                "high": candle.high,
+                "low": candle.low,
+                "close": candle.close,
+                "volume": candle.volume,
+                "source": self.SOURCE,
+            })
+        if data:
+            await db.prisma.candle.create_many(data=data, skip_duplicates=True)
+            logger.info(f"Back-filled {len(data)} {interval} candles for {symbol}")
+        return live
+
+    async def get_candles(self, symbol: str, interval: str, start: datetime, end: datetime) -> List[Candle]:
+        """Candles with open times in [start, end], filling gaps from the exchange.
+
+        Raises ValueError for unknown intervals or ranges over MAX_CANDLES.
+        """
+        step = INTERVALS.get(interval)
+        if not step:
+            raise ValueError(f"Unsupported interval {interval}")
+        symbol = symbol.upper()
+        start = self.align(start, step)
+        end = min(end, datetime.now(timezone.utc))
+        if end < start:
+            raise ValueError("Range end is before its start")
+
+        count = int((end - start).total_seconds() // step) + 1
+        if count > self.MAX_CANDLES:
+            raise ValueError(f"Range covers {count} candles; the maximum is {self.MAX_CANDLES}")
+        expected = [start + timedelta(seconds=step * i) for i in range(count)]
+
+        where = {"symbol": symbol, "interval": interval, "openTime": {"gte": start, "lte": end}}
+        records = await db.prisma.candle.find_many(where=where, order={"openTime": "asc"})
+        stored = {record.openTime for record in records}
+
+        gaps = self._gaps(expected, stored, step)
+        live: List[Candle] = []
+        if gaps:
+            try:
+                for first, last in gaps:
+                    live.extend(await self._backfill(symbol, interval, first, last, step))
+            except Exception as e:
+                # Serve what we have rather than failing the whole request
+                logger.error(f"Candle back-fill failed for {symbol} {interval}: {e}")
+            records = await db.prisma.candle.find_many(where=where, order={"openTime": "asc"})
+
+        candles = [self._to_schema(record) for record in records]
+        return candles + [candle for candle in live if candle.open_time not in stored]
+
+# Create singleton instance
+candle_service = CandleService()
@@ -0,0 +1,129 @@
+from typing import Any, Dict, FrozenSet, List, Optional, Tuple
+from loguru import logger
+import json
+import re
+
+from prisma import Json
+
+from app.core.db import db
+from app.models.schemas import CapturedRequestInfo
+
+REDACTED = "[REDACTED]"
+SENSITIVE_HEADERS = {
+    "authorization", "x-api-key", "cookie", "set-cookie", "x-totp-code",
+    "x-signature", "x-forwarded-client-cert", "x-telegram-bot-api-secret-token",
+}
+# JSON fields and query parameters whose values are never stored
+_SENSITIVE_NAME = re.compile(r"(password|secret|token|api_?key|private|signature)", re.IGNORECASE)
+# Exact names too generic for the pattern: raw API keys from POST /keys, the
+# TOTP enrollment URI (it embeds the shared secret) and TOTP codes
+_SENSITIVE_FIELDS = frozenset({"key", "provisioning_uri", "secret", "otpauth_uri", "totp_code"})
+# "code" is a TOTP code or OIDC authorization code in requests, but only the
+# error code in response envelopes, which is worth keeping
+_SENSITIVE_REQUEST_FIELDS = _SENSITIVE_FIELDS | {"code"}
+
+def _is_sensitive(name: str, fields: FrozenSet[str] = _SENSITIVE_FIELDS) -> bool:
+    return name.lower() in fields or bool(_SENSITIVE_NAME.search(name))
+
+def redact_headers(headers: Dict[str, str]) -> Dict[str, str]:
+    return {name: REDACTED if name.lower() in SENSITIVE_HEADERS else value for name, value in headers.items()}
+
+def redact_json(value: Any, fields: FrozenSet[str] = _SENSITIVE_FIELDS) -> Any:
+    if isinstance(value, dict):
+        return {k: REDACTED if _is_sensitive(k, fields) else redact_json(v, fields) for k, v in value.items()}
+    if isinstance(value, list):
+        return [redact_json(v, fields) for v in value]
+    return value
+
+def redact_query(query: str) -> str:
+    """Mask sensitive parameters of a request query string or form body"""
+    parts = []
+    for part in query.split("&") if query else []:
+        name, sep, _ = part.partition("=")
+        parts.append(f"{name}{sep}{REDACTED}" if sep and _is_sensitive(name, _SENSITIVE_REQUEST_FIELDS) else part)
+    return "&".join(parts)
+
+def redact_body(body: bytes, content_type: str, request: bool = False) -> Tuple[Optional[str], bool, bool]:
+    """Text form of a body with sensitive fields masked; binary bodies are summarized.
+
+    Returns (text, redacted, summarized) so callers know whether the stored
+    text still matches what was sent.
+    """
+    if not body:
+        return None, False, False
+    if "json" in content_type:
+        try:
+            parsed = json.loads(body)
+            masked = redact_json(parsed, _SENSITIVE_REQUEST_FIELDS if request else _SENSITIVE_FIELDS)
+            return json.dumps(masked), masked != parsed, False
+        except ValueError:
+            pass
+    if content_type.startswith("text/") or "json" in content_type or "x-www-form-urlencoded" in content_type:
+        text = body.decode("utf-8", errors="replace")
+        if "x-www-form-urlencoded" in content_type:
+            masked = redact_query(text)
+            return masked, masked != text, False
+        return text, False, False
+    return f"[{len(body)} bytes of {content_type or 'unknown content'}]", False, True
+
+class CaptureService:
+    """Stores redacted request/response pairs for debugging and replay"""
+    _instance: Optional['CaptureService'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(CaptureService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._initialized = True
+
+    def _to_schema(self, record) -> CapturedRequestInfo:
+        return CapturedRequestInfo(
+            id=record.id,
+            request_id=record.requestId,
+            method=record.method,
+            path=record.path,
+            query=record.query,
+            subject=record.subject,
+            request_headers=dict(record.requestHeaders or {}),
+            request_body=record.requestBody,
+            body_truncated=record.bodyTruncated,
+            body_redacted=record.bodyRedacted,
+            body_summarized=record.bodySummarized,
+            status_code=record.statusCode,
+            response_headers=dict(record.responseHeaders or {}),
+            response_body=record.responseBody,
+            duration_ms=record.durationMs,
+            created_at=record.createdAt,
+        )
+
+    async def record(self, data: Dict[str, Any]):
+        """Store one capture; failures are logged, never raised into the request"""
+        try:
+            await db.prisma.capturedrequest.create(
+                data={
+                    **data,
+                    "requestHeaders": Json(data["requestHeaders"]),
+                    "responseHeaders": Json(data["responseHeaders"]),
+                }
+            )
+        except Exception as e:
+            logger.error(f"Error storing captured request {data.get('path')}: {e}")
+
+    async def list(self, path: Optional[str] = None, limit: int = 50) -> List[CapturedRequestInfo]:
+        where = {"path": {"startswith": path}} if path else {}
+        records = await db.prisma.capturedrequest.find_many(where=where, order={"createdAt": "desc"}, take=limit)
+        return [self._to_schema(r) for r in records]
+
+    async def get(self, capture_id: str) -> Optional[CapturedRequestInfo]:
+        record = await db.prisma.capturedrequest.find_unique(where={"id": capture_id})
+        return self._to_schema(record) if record else None
+
+    async def purge(self) -> int:
+        return await db.prisma.capturedrequest.delete_many()
+
+# Create singleton instance
+capture_service = CaptureService()
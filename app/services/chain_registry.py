@@ -0,0 +1,230 @@
+from typing import Any, Dict, List, Optional
+from dataclasses import dataclass, field
+from datetime import datetime, timezone
+from loguru import logger
+import asyncio
+import time
+import httpx
+
+from env import env
+from app.core.http_client import create_client
+
+EVM = "evm"
+SOLANA = "solana"
+
+@dataclass
+class RpcEndpoint:
+    url: str
+    healthy: bool = True
+    latency_ms: Optional[float] = None
+    last_error: Optional[str] = None
+    checked_at: Optional[datetime] = None
+
+@dataclass
+class Chain:
+    name: str
+    kind: str  # EVM or SOLANA
+    native_symbol: str
+    decimals: int
+    chain_id: Optional[int] = None  # EVM only
+    endpoints: List[RpcEndpoint] = field(default_factory=list)
+
+# Built-in chains with public RPC endpoints; CHAIN_RPC_URLS replaces the endpoints
+DEFAULT_CHAINS: Dict[str, Dict[str, Any]] = {
+    "ethereum": {"kind": EVM, "chain_id": 1, "native_symbol": "ETH", "decimals": 18, "rpc_urls": []},
+    "optimism": {"kind": EVM, "chain_id": 10, "native_symbol": "ETH", "decimals": 18, "rpc_urls": ["https://mainnet.optimism.io"]},
+    "bsc": {"kind": EVM, "chain_id": 56, "native_symbol": "BNB", "decimals": 18, "rpc_urls": ["https://bsc-dataseed.binance.org"]},
+    "polygon": {"kind": EVM, "chain_id": 137, "native_symbol": "POL", "decimals": 18, "rpc_urls": ["https://polygon-rpc.com"]},
+    "base": {"kind": EVM, "chain_id": 8453, "native_symbol": "ETH", "decimals": 18, "rpc_urls": ["https://mainnet.base.org"]},
+    "arbitrum": {"kind": EVM, "chain_id": 42161, "native_symbol": "ETH", "decimals": 18, "rpc_urls": ["https://arb1.arbitrum.io/rpc"]},
+    "solana": {"kind": SOLANA, "native_symbol": "SOL", "decimals": 9, "rpc_urls": ["https://api.mainnet-beta.solana.com"]},
+}
+
+class RpcError(Exception):
+    """Raised when a JSON-RPC call fails on every endpoint or returns an error"""
+
+class ChainRegistry:
+    """Supported chains and their RPC endpoints, with health checks and failover.
+
+    Calls go to healthy endpoints first, in configured order; an endpoint
+    that fails is marked unhealthy until a health check or call succeeds.
+    Chains changed through /admin/chains override the configuration until
+    restart.
+    """
+    _instance: Optional['ChainRegistry'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(ChainRegistry, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.client = create_client()
+            self._overrides: Dict[str, Dict[str, Any]] = {}
+            self._running = False
+            self._task: Optional[asyncio.Task] = None
+            self.refresh()
+            self._initialized = True
+
+    def _build(self, name: str, spec: Dict[str, Any], previous: Optional[Chain]) -> Chain:
+        # Keep health state for endpoints that survive a rebuild
+        known = {e.url: e for e in previous.endpoints} if previous else {}
+        return Chain(
+            name=name,
+            kind=spec["kind"],
+            native_symbol=spec["native_symbol"],
+            decimals=spec["decimals"],
+            chain_id=spec.get("chain_id"),
+            endpoints=[known.get(url) or RpcEndpoint(url=url) for url in spec["rpc_urls"]],
+        )
+
+    def build(self) -> Dict[str, Chain]:
+        """Chains for the current settings plus runtime overrides, without applying them.
+
+        Raises ValueError if CHAIN_RPC_URLS is malformed.
+        """
+        specs = {name: dict(spec) for name, spec in DEFAULT_CHAINS.items()}
+        specs["ethereum"]["rpc_urls"] = [env.ETH_RPC_URL]
+        for name, urls in env.CHAIN_RPC_URLS.items():
+            if name in specs:
+                urls = [urls] if isinstance(urls, str) else urls
+                if not isinstance(urls, list) or not all(isinstance(url, str) for url in urls):
+                    raise ValueError(f"CHAIN_RPC_URLS[{name}] must be a URL or a list of URLs")
+                specs[name]["rpc_urls"] = urls
+        specs.update(self._overrides)
+
+        previous = getattr(self, "chains", {})
+        return {name: self._build(name, spec, previous.get(name)) for name, spec in specs.items()}
+
+    def refresh(self, chains: Optional[Dict[str, Chain]] = None):
+        """Rebuild chains from the current settings, or swap in already built ones"""
+        self.chains: Dict[str, Chain] = chains if chains is not None else self.build()
+
+    def get(self, name: str, kind: Optional[str] = None) -> Chain:
+        """Look up a chain, optionally of a given kind. Raises ValueError if unknown"""
+        chain = self.chains.get(name.lower())
+        if not chain or (kind and chain.kind != kind):
+            supported = [c.name for c in self.chains.values() if not kind or c.kind == kind]
+            raise ValueError(f"Unsupported chain {name}; supported: {', '.join(supported)}")
+        return chain
+
+    def names(self, kind: Optional[str] = None) -> List[str]:
+        return [c.name for c in self.chains.values() if not kind or c.kind == kind]
+
+    def configure(self, name: str, spec: Dict[str, Any]) -> Chain:
+        """Add a chain or replace one's settings at runtime"""
+        name = name.lower()
+        current = self.chains.get(name)
+        merged = {
+            "kind": current.kind if current else EVM,
+            "native_symbol": current.native_symbol if current else None,
+            "decimals": current.decimals if current else 18,
+            "chain_id": current.chain_id if current else None,
+            "rpc_urls": [e.url for e in current.endpoints] if current else [],
+        }
+        merged.update({key: value for key, value in spec.items() if value is not None})
+        if not merged["native_symbol"]:
+            raise ValueError("native_symbol is required for new chains")
+        if merged["kind"] == EVM and merged["chain_id"] is None:
+            raise ValueError("chain_id is required for EVM chains")
+        if not merged["rpc_urls"]:
+            raise ValueError("At least one RPC URL is required")
+
+        self._overrides[name] = merged
+        self.refresh()
+        logger.info(f"Chain {name} configured with {len(merged['rpc_urls'])} RPC endpoint(s)")
+        return self.chains[name]
+
+    def reset(self, name: str) -> bool:
+        """Drop a runtime override; returns False if the chain had none"""
+        if self._overrides.pop(name.lower(), None) is None:
+            return False
+        self.refresh()
+        logger.info(f"Chain {name} reset to its configured settings")
+        return True
+
+    async def _call(self, endpoint: RpcEndpoint, method: str, params: List[Any]) -> Any:
+        """One JSON-RPC call. Transport failures raise httpx errors; RPC errors raise RpcError"""
+        response = await self.client.post(endpoint.url, json={"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+        response.raise_for_status()
+        data = response.json()
+        if "error" in data:
+            error = data["error"]
+            raise RpcError(f"{method} failed: {error.get('message', error) if isinstance(error, dict) else error}")
+        return data.get("result")
+
+    def _mark(self, endpoint: RpcEndpoint, error: Optional[str], started: float):
+        if error and endpoint.healthy:
+            logger.warning(f"RPC endpoint {endpoint.url} marked unhealthy: {error}")
+        endpoint.healthy = error is None
+        endpoint.last_error = error
+        endpoint.latency_ms = round((time.monotonic() - started) * 1000, 1)
+        endpoint.checked_at = datetime.now(timezone.utc)
+
+    async def rpc(self, chain_name: str, method: str, params: List[Any]) -> Any:
+        """Call a JSON-RPC method on a chain, failing over between endpoints"""
+        chain = self.get(chain_name)
+        # Healthy endpoints first; unhealthy ones are still a last resort
+        endpoints = sorted(chain.endpoints, key=lambda e: not e.healthy)
+        failures = []
+        for endpoint in endpoints:
+            started = time.monotonic()
+            try:
+                result = await self._call(endpoint, method, params)
+            except RpcError:
+                # The node answered; the request itself was refused
+                self._mark(endpoint, None, started)
+                raise
+            except (httpx.HTTPError, ValueError) as e:
+                self._mark(endpoint, str(e) or type(e).__name__, started)
+                failures.append(endpoint.url)
+                continue
+            self._mark(endpoint, None, started)
+            return result
+        raise RpcError(f"{method} failed on every {chain.name} endpoint: {', '.join(failures) or 'none configured'}")
+
+    async def check_health(self):
+        """Probe every endpoint of every chain"""
+        async def probe(chain: Chain, endpoint: RpcEndpoint):
+            method = "getHealth" if chain.kind == SOLANA else "eth_blockNumber"
+            started = time.monotonic()
+            try:
+                await self._call(endpoint, method, [])
+                self._mark(endpoint, None, started)
+            except Exception as e:
+                self._mark(endpoint, str(e) or type(e).__name__, started)
+
+        await asyncio.gather(*(
+            probe(chain, endpoint) for chain in self.chains.values() for endpoint in chain.endpoints
+        ))
+
+    async def start(self):
+        """Start periodic health checks"""
+        if self._running or env.CHAIN_HEALTH_CHECK_INTERVAL <= 0:
+            return
+        self._running = True
+        self._task = asyncio.create_task(self._health_loop())
+
+    async def _health_loop(self):
+        while self._running:
+            try:
+                await self.check_health()
+            except Exception as e:
+                logger.error(f"Error in chain health check: {e}")
+            await asyncio.sleep(env.CHAIN_HEALTH_CHECK_INTERVAL)
+
+    async def close(self):
+        """Stop health checks and close the RPC client"""
+        self._running = False
+        if self._task:
+            self._task.cancel()
+            try:
+                await self._task
+            except asyncio.CancelledError:
+                pass
+        await self.client.aclose()
+
+# Create singleton instance
+chain_registry = ChainRegistry()
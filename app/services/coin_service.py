@@ -1,10 +1,10 @@
 from typing import List, Dict, Any, Optional
 from datetime import datetime, timedelta
-import httpx
 from loguru import logger
 
 from app.models.coin import CoinCreate, CoinUpdate, CoinInDB
 from app.services.cache_service import CacheService
+from app.core.http_client import create_client
 from app.core.db import db
 
 class CoinService:
@@ -94,7 +94,7 @@ class CoinService:
         }
         
         try:
-            async with httpx.AsyncClient() as client:
+            async with create_client() as client:
                 response = await client.get(url, params=params)
                 response.raise_for_status()
                 return response.json()
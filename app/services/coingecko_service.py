@@ -1,10 +1,10 @@
 from typing import Dict, Optional, List
-import httpx
 from loguru import logger
 from datetime import datetime
 import asyncio
 
 from env import env
+from app.core.http_client import create_client
 from app.models.schemas import MarketData
 from app.services.cache_service import CacheService
 
@@ -22,7 +22,7 @@ class CoinGeckoService:
     def __init__(self):
         if not self._initialized:
             self.api_key = env.COINGECKO_API_KEY
-            self.client = httpx.AsyncClient(
+            self.client = create_client(
                 base_url=self.BASE_URL,
                 timeout=30.0,
                 headers={
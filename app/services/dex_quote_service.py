@@ -0,0 +1,160 @@
+from typing import Dict, List, Optional, Tuple
+from loguru import logger
+import asyncio
+
+from env import env
+from app.core.http_client import create_client
+from app.models.schemas import SwapQuote
+from app.services.cache_service import CacheService
+from app.services.chain_registry import SOLANA, chain_registry
+
+# The 0x API host serving each chain
+ZEROX_HOSTS: Dict[str, str] = {
+    "ethereum": "https://api.0x.org",
+    "optimism": "https://optimism.api.0x.org",
+    "bsc": "https://bsc.api.0x.org",
+    "polygon": "https://polygon.api.0x.org",
+    "base": "https://base.api.0x.org",
+    "arbitrum": "https://arbitrum.api.0x.org",
+}
+
+class QuoteError(Exception):
+    """Raised by an aggregator that can't quote a swap"""
+
+class DexQuoteService:
+    """Swap quotes from DEX aggregators, normalized for comparison"""
+    _instance: Optional['DexQuoteService'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(DexQuoteService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.cache = CacheService()
+            self.client = create_client()
+            self._cache_key_prefix = "swap_quote:"
+            self._initialized = True
+
+    async def close(self):
+        """Close HTTP client"""
+        await self.client.aclose()
+
+    def _aggregators_for(self, chain: str):
+        if chain_registry.get(chain).kind == SOLANA:
+            return [("jupiter", self._jupiter)] if chain == "solana" else []
+        aggregators = []
+        if env.ZEROX_API_KEY and chain in ZEROX_HOSTS:
+            aggregators.append(("0x", self._zerox))
+        if env.ONEINCH_API_KEY:
+            aggregators.append(("1inch", self._oneinch))
+        return aggregators
+
+    async def _get(self, url: str, params: Dict, headers: Optional[Dict] = None) -> Dict:
+        response = await self.client.get(url, params=params, headers=headers)
+        if response.status_code >= 400:
+            raise QuoteError(f"HTTP {response.status_code}: {response.text[:200]}")
+        return response.json()
+
+    async def _zerox(self, chain: str, sell: str, buy: str, amount: str) -> SwapQuote:
+        data = await self._get(
+            f"{ZEROX_HOSTS[chain]}/swap/v1/price",
+            {"sellToken": sell, "buyToken": buy, "sellAmount": amount},
+            headers={"0x-api-key": env.ZEROX_API_KEY},
+        )
+        impact = data.get("estimatedPriceImpact")
+        return SwapQuote(
+            aggregator="0x",
+            chain=chain,
+            sell_token=sell,
+            buy_token=buy,
+            sell_amount=str(data["sellAmount"]),
+            buy_amount=str(data["buyAmount"]),
+            price_impact_pct=float(impact) if impact is not None else None,
+            estimated_gas=int(data["estimatedGas"]) if data.get("estimatedGas") else None,
+            route=[s["name"] for s in data.get("sources", []) if float(s.get("proportion", 0)) > 0],
+        )
+
+    async def _oneinch(self, chain: str, sell: str, buy: str, amount: str) -> SwapQuote:
+        data = await self._get(
+            f"{env.ONEINCH_API_URL}/{chain_registry.get(chain).chain_id}/quote",
+            {"src": sell, "dst": buy, "amount": amount, "includeGas": "true", "includeProtocols": "true"},
+            headers={"Authorization": f"Bearer {env.ONEINCH_API_KEY}"},
+        )
+        # protocols is a list of routes, each a list of hops, each a list of splits
+        route = sorted({
+            part["name"]
+            for path in data.get("protocols", [])
+            for hop in path
+            for part in hop
+        })
+        return SwapQuote(
+            aggregator="1inch",
+            chain=chain,
+            sell_token=sell,
+            buy_token=buy,
+            sell_amount=amount,
+            buy_amount=str(data["dstAmount"]),
+            estimated_gas=int(data["gas"]) if data.get("gas") else None,
+            route=route,
+        )
+
+    async def _jupiter(self, chain: str, sell: str, buy: str, amount: str) -> SwapQuote:
+        data = await self._get(
+            f"{env.JUPITER_API_URL}/quote",
+            {"inputMint": sell, "outputMint": buy, "amount": amount},
+        )
+        impact = data.get("priceImpactPct")
+        return SwapQuote(
+            aggregator="jupiter",
+            chain=chain,
+            sell_token=sell,
+            buy_token=buy,
+            sell_amount=str(data["inAmount"]),
+            buy_amount=str(data["outAmount"]),
+            # Jupiter reports impact as a fraction
+            price_impact_pct=float(impact) * 100 if impact is not None else None,
+            route=[step["swapInfo"]["label"] for step in data.get("routePlan", [])],
+        )
+
+    async def get_quotes(self, chain: str, sell: str, buy: str, amount: str) -> Tuple[List[SwapQuote], Dict[str, str]]:
+        """Query every configured aggregator for the chain.
+
+        Returns the quotes sorted best (largest output) first, plus errors by
+        aggregator. Raises ValueError for unsupported chains or when no
+        aggregator is configured for the chain.
+        """
+        chain = chain_registry.get(chain).name
+        aggregators = self._aggregators_for(chain)
+        if not aggregators:
+            raise ValueError(f"No DEX aggregator is configured for {chain}")
+
+        cache_key = f"{self._cache_key_prefix}{chain}:{sell.lower()}:{buy.lower()}:{amount}"
+        cached = await self.cache.get_key(cache_key)
+        if cached:
+            return [SwapQuote(**q) for q in cached["quotes"]], cached["errors"]
+
+        results = await asyncio.gather(
+            *(fetch(chain, sell, buy, amount) for _, fetch in aggregators),
+            return_exceptions=True
+        )
+        quotes, errors = [], {}
+        for (name, _), result in zip(aggregators, results):
+            if isinstance(result, Exception):
+                logger.warning(f"{name} quote failed for {chain} {sell}->{buy}: {result}")
+                errors[name] = str(result) if isinstance(result, QuoteError) else "Quote request failed"
+            else:
+                quotes.append(result)
+        quotes.sort(key=lambda q: int(q.buy_amount), reverse=True)
+        if quotes:
+            await self.cache.set_key(
+                cache_key,
+                {"quotes": [q.model_dump() for q in quotes], "errors": errors},
+                expiry=env.QUOTE_CACHE_TTL
+            )
+        return quotes, errors
+
+# Create singleton instance
+dex_quote_service = DexQuoteService()
@@ -0,0 +1,63 @@
+from typing import Any, Dict, List, Optional, Set
+from datetime import datetime, timezone
+from loguru import logger
+import asyncio
+import fnmatch
+
+class Subscription:
+    """A subscriber's filtered, bounded queue of events"""
+    QUEUE_SIZE = 100
+
+    def __init__(self, patterns: List[str]):
+        self.patterns = patterns or ["*"]
+        self.queue: asyncio.Queue = asyncio.Queue(maxsize=self.QUEUE_SIZE)
+        self.dropped = 0
+
+    def matches(self, topic: str) -> bool:
+        return any(fnmatch.fnmatchcase(topic, pattern) for pattern in self.patterns)
+
+    def offer(self, event: Dict[str, Any]):
+        """Queue an event, dropping the oldest one if the subscriber is too slow"""
+        if self.queue.full():
+            self.queue.get_nowait()
+            self.dropped += 1
+        self.queue.put_nowait(event)
+
+class EventBus:
+    """In-process pub/sub for bot activity (auth, key and alert events)"""
+    _instance: Optional['EventBus'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(EventBus, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._subscriptions: Set[Subscription] = set()
+            self._initialized = True
+
+    def subscribe(self, patterns: List[str]) -> Subscription:
+        """Subscribe to topics matching any of the patterns, e.g. "auth.*" """
+        subscription = Subscription(patterns)
+        self._subscriptions.add(subscription)
+        return subscription
+
+    def unsubscribe(self, subscription: Subscription):
+        self._subscriptions.discard(subscription)
+
+    def publish(self, topic: str, data: Dict[str, Any]):
+        """Deliver an event to all matching subscribers without blocking"""
+        event = {
+            "topic": topic,
+            "data": data,
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+        }
+        for subscription in list(self._subscriptions):
+            if subscription.matches(topic):
+                subscription.offer(event)
+        logger.debug(f"Event published: {topic}")
+
+# Create singleton instance
+event_bus = EventBus()
@@ -0,0 +1,110 @@
+from typing import Any, List, Optional
+from datetime import datetime, timezone
+from loguru import logger
+
+from env import env
+from app.models.schemas import GasEstimate, GasTier
+from app.services.cache_service import CacheService
+from app.services.chain_registry import EVM, RpcError, chain_registry
+
+# Reward percentiles sampled from recent blocks for each tier
+TIER_PERCENTILES = {"slow": 10, "standard": 50, "fast": 90}
+FEE_HISTORY_BLOCKS = 20
+GWEI = 10 ** 9
+
+class GasError(Exception):
+    """Raised when a chain's RPC can't provide fee data"""
+
+class GasService:
+    """Fee estimates per chain from eth_feeHistory, with a legacy gas price fallback"""
+    _instance: Optional['GasService'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(GasService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.cache = CacheService()
+            self._cache_key_prefix = "gas:"
+            self._initialized = True
+
+    async def _rpc(self, chain: str, method: str, params: List[Any]) -> Any:
+        try:
+            return await chain_registry.rpc(chain, method, params)
+        except RpcError as e:
+            raise GasError(str(e))
+
+    @staticmethod
+    def _gwei(wei: float) -> float:
+        return round(wei / GWEI, 4)
+
+    async def _eip1559(self, chain: str) -> Optional[GasEstimate]:
+        """Estimate from recent priority fees; None if the chain has no base fee"""
+        history = await self._rpc(chain, "eth_feeHistory", [hex(FEE_HISTORY_BLOCKS), "latest", list(TIER_PERCENTILES.values())])
+        base_fees = (history or {}).get("baseFeePerGas") or []
+        if not base_fees or int(base_fees[-1], 16) == 0:
+            return None
+
+        # The last entry is the base fee of the next block
+        next_base_fee = int(base_fees[-1], 16)
+        rewards = [[int(r, 16) for r in block] for block in history.get("reward") or [] if block]
+        tiers = {}
+        for i, name in enumerate(TIER_PERCENTILES):
+            samples = sorted(block[i] for block in rewards)
+            priority_fee = samples[len(samples) // 2] if samples else 0
+            # Leave room for the base fee to rise for a few full blocks
+            tiers[name] = GasTier(
+                max_fee_gwei=self._gwei(2 * next_base_fee + priority_fee),
+                priority_fee_gwei=self._gwei(priority_fee),
+            )
+        return GasEstimate(
+            chain=chain,
+            eip1559=True,
+            base_fee_gwei=self._gwei(next_base_fee),
+            block_number=int(history["oldestBlock"], 16) + len(base_fees) - 2,
+            updated_at=datetime.now(timezone.utc),
+            **tiers,
+        )
+
+    async def _legacy(self, chain: str) -> GasEstimate:
+        """Scale eth_gasPrice for chains without EIP-1559"""
+        gas_price = int(await self._rpc(chain, "eth_gasPrice", []), 16)
+        tier = lambda factor: GasTier(max_fee_gwei=self._gwei(gas_price * factor))
+        return GasEstimate(
+            chain=chain,
+            eip1559=False,
+            slow=tier(0.9),
+            standard=tier(1.0),
+            fast=tier(1.2),
+            updated_at=datetime.now(timezone.utc),
+        )
+
+    async def get_estimate(self, chain: str) -> GasEstimate:
+        """Slow/standard/fast fees for a chain.
+
+        Raises ValueError for unknown chains and GasError when the RPC fails.
+        """
+        chain = chain_registry.get(chain, kind=EVM).name
+
+        cache_key = f"{self._cache_key_prefix}{chain}"
+        cached = await self.cache.get_key(cache_key)
+        if cached:
+            return GasEstimate(**cached)
+
+        try:
+            estimate = await self._eip1559(chain)
+        except GasError as e:
+            # Some RPCs don't implement eth_feeHistory at all
+            logger.debug(f"eth_feeHistory unavailable on {chain}: {e}")
+            estimate = None
+        if estimate is None:
+            estimate = await self._legacy(chain)
+
+        await self.cache.set_key(cache_key, estimate.model_dump(mode="json"), expiry=env.GAS_CACHE_TTL)
+        return estimate
+
+# Create singleton instance
+gas_service = GasService()
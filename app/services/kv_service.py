@@ -0,0 +1,153 @@
+from typing import Any, List, Optional
+from datetime import datetime, timedelta, timezone
+from loguru import logger
+from prisma import Json
+from prisma.errors import UniqueViolationError
+import json
+
+from env import env
+from app.core.db import db
+from app.models.schemas import KvEntry
+
+class KvConflict(Exception):
+    """Raised when a write's expected revision doesn't match the stored one"""
+
+    def __init__(self, revision: Optional[int]):
+        super().__init__(f"Current revision is {revision}")
+        self.revision = revision
+
+class KvValueTooLarge(Exception):
+    """Raised when a value exceeds KV_MAX_VALUE_BYTES"""
+
+class KvService:
+    _instance: Optional['KvService'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(KvService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._initialized = True
+
+    def _to_schema(self, record) -> KvEntry:
+        """Convert a Prisma KvEntry record to the API schema"""
+        return KvEntry(
+            namespace=record.namespace,
+            key=record.key,
+            value=record.value,
+            revision=record.revision,
+            expires_at=record.expiresAt,
+            updated_at=record.updatedAt,
+        )
+
+    @staticmethod
+    def _expired(record, now: datetime) -> bool:
+        return record.expiresAt is not None and record.expiresAt <= now
+
+    async def _find_live(self, namespace: str, key: str):
+        """Fetch an entry, deleting it instead if its TTL has passed"""
+        record = await db.prisma.kventry.find_unique(
+            where={"namespace_key": {"namespace": namespace, "key": key}}
+        )
+        if record and self._expired(record, datetime.now(timezone.utc)):
+            await db.prisma.kventry.delete_many(where={"id": record.id, "revision": record.revision})
+            return None
+        return record
+
+    async def get(self, namespace: str, key: str) -> Optional[KvEntry]:
+        """Get a live entry, returns None if it doesn't exist or has expired"""
+        record = await self._find_live(namespace, key)
+        return self._to_schema(record) if record else None
+
+    async def list(self, namespace: str, prefix: str = "", limit: int = 100) -> List[KvEntry]:
+        """Live entries in a namespace, ordered by key"""
+        records = await db.prisma.kventry.find_many(
+            where={
+                "namespace": namespace,
+                "key": {"startswith": prefix},
+                "OR": [{"expiresAt": None}, {"expiresAt": {"gt": datetime.now(timezone.utc)}}],
+            },
+            order={"key": "asc"},
+            take=limit,
+        )
+        return [self._to_schema(record) for record in records]
+
+    async def put(
+        self,
+        namespace: str,
+        key: str,
+        value: Any,
+        ttl: Optional[int] = None,
+        expected_revision: Optional[int] = None,
+    ) -> KvEntry:
+        """Create or replace an entry.
+
+        With expected_revision the write only succeeds if the stored entry is
+        at that revision; 0 means the entry must not exist yet. Concurrent
+        writers racing on the same revision are also rejected, so a
+        read-modify-write loop never loses an update.
+        """
+        if len(json.dumps(value).encode()) > env.KV_MAX_VALUE_BYTES:
+            raise KvValueTooLarge(f"Values are limited to {env.KV_MAX_VALUE_BYTES} bytes")
+
+        expires_at = datetime.now(timezone.utc) + timedelta(seconds=ttl) if ttl else None
+        existing = await self._find_live(namespace, key)
+        current = existing.revision if existing else 0
+        if expected_revision is not None and expected_revision != current:
+            raise KvConflict(current if existing else None)
+
+        if existing is None:
+            try:
+                record = await db.prisma.kventry.create(
+                    data={"namespace": namespace, "key": key, "value": Json(value), "expiresAt": expires_at}
+                )
+            except UniqueViolationError:
+                # Someone created it between our read and write
+                raise KvConflict(None)
+        else:
+            updated = await db.prisma.kventry.update_many(
+                where={"id": existing.id, "revision": existing.revision},
+                data={"value": Json(value), "expiresAt": expires_at, "revision": {"increment": 1}},
+            )
+            if updated == 0:
+                raise KvConflict(None)
+            record = await db.prisma.kventry.find_unique(where={"id": existing.id})
+
+        logger.debug(f"KV {namespace}/{key} written at revision {record.revision}")
+        return self._to_schema(record)
+
+    async def delete(self, namespace: str, key: str, expected_revision: Optional[int] = None) -> bool:
+        """Delete an entry, returns False if it doesn't exist"""
+        existing = await self._find_live(namespace, key)
+        if not existing:
+            if expected_revision is not None:
+                raise KvConflict(None)
+            return False
+        if expected_revision is not None and expected_revision != existing.revision:
+            raise KvConflict(existing.revision)
+
+        deleted = await db.prisma.kventry.delete_many(
+            where={"id": existing.id, "revision": existing.revision}
+        )
+        if deleted == 0 and expected_revision is not None:
+            raise KvConflict(None)
+        return deleted > 0
+
+    async def purge_expired(self) -> int:
+        """Delete every entry whose TTL has passed"""
+        try:
+            deleted = await db.prisma.kventry.delete_many(
+                where={"expiresAt": {"lte": datetime.now(timezone.utc)}}
+            )
+            if deleted:
+                logger.info(f"Purged {deleted} expired KV entries")
+            return deleted
+        except Exception as e:
+            logger.error(f"Error purging expired KV entries: {e}")
+            return 0
+
+# Create singleton instance
+kv_service = KvService()
\ No newline at end of file
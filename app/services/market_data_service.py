@@ -0,0 +1,151 @@
+from typing import Dict, List, Optional
+from datetime import datetime, timezone
+from loguru import logger
+import asyncio
+
+from env import env
+from app.core.http_client import create_client
+from app.models.schemas import MarketPrice
+from app.services.cache_service import CacheService
+from app.services.price_service import price_service
+
+class PriceProvider:
+    """A source of USD spot prices"""
+    name = "provider"
+
+    async def get_prices(self, symbols: List[str]) -> Dict[str, MarketPrice]:
+        """Return prices for the symbols this provider knows; omit the rest"""
+        raise NotImplementedError
+
+    async def close(self):
+        pass
+
+class CoinGeckoProvider(PriceProvider):
+    name = "coingecko"
+
+    async def get_prices(self, symbols: List[str]) -> Dict[str, MarketPrice]:
+        now = datetime.now(timezone.utc)
+        data = await price_service.get_prices(symbols)
+        return {
+            symbol: MarketPrice(
+                symbol=symbol,
+                price_usd=stats["price"],
+                change_24h=stats.get("change_24h"),
+                volume_24h=stats.get("volume_24h"),
+                market_cap=stats.get("market_cap"),
+                source=self.name,
+                fetched_at=now,
+            )
+            for symbol, stats in data.items()
+            if stats.get("price") is not None
+        }
+
+class BinanceProvider(PriceProvider):
+    """Spot prices from Binance USDT pairs"""
+    name = "binance"
+    QUOTE_ASSET = "USDT"
+
+    def __init__(self):
+        self.client = create_client(base_url=env.BINANCE_BASE_URL)
+
+    async def close(self):
+        await self.client.aclose()
+
+    async def _ticker(self, symbol: str) -> Optional[MarketPrice]:
+        try:
+            response = await self.client.get("/api/v3/ticker/24hr", params={"symbol": f"{symbol}{self.QUOTE_ASSET}"})
+            if response.status_code == 400:
+                return None  # Unknown pair
+            response.raise_for_status()
+            data = response.json()
+            return MarketPrice(
+                symbol=symbol,
+                price_usd=float(data["lastPrice"]),
+                change_24h=float(data["priceChangePercent"]),
+                volume_24h=float(data["quoteVolume"]),
+                high_24h=float(data["highPrice"]),
+                low_24h=float(data["lowPrice"]),
+                source=self.name,
+                fetched_at=datetime.now(timezone.utc),
+            )
+        except Exception as e:
+            logger.error(f"Binance ticker lookup failed for {symbol}: {e}")
+            return None
+
+    async def get_prices(self, symbols: List[str]) -> Dict[str, MarketPrice]:
+        results = await asyncio.gather(*(self._ticker(symbol) for symbol in symbols))
+        return {price.symbol: price for price in results if price}
+
+    async def get_klines(self, symbol: str, interval: str, start_ms: int, end_ms: int, limit: int = 1000) -> List[list]:
+        """Raw klines ([open time, open, high, low, close, volume, ...]) for a USDT pair"""
+        response = await self.client.get(
+            "/api/v3/klines",
+            params={
+                "symbol": f"{symbol}{self.QUOTE_ASSET}",
+                "interval": interval,
+                "startTime": start_ms,
+                "endTime": end_ms,
+                "limit": limit,
+            }
+        )
+        response.raise_for_status()
+        return response.json()
+
+PROVIDERS = {
+    CoinGeckoProvider.name: CoinGeckoProvider,
+    BinanceProvider.name: BinanceProvider,
+}
+
+class MarketDataService:
+    """Shared price feed: cached, with failover across providers in PRICE_PROVIDERS order"""
+    _instance: Optional['MarketDataService'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(MarketDataService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.cache = CacheService()
+            self.providers: List[PriceProvider] = [PROVIDERS[name]() for name in env.PRICE_PROVIDERS]
+            self._cache_key_prefix = "market_price:"
+            self._initialized = True
+
+    async def close(self):
+        """Close provider HTTP clients"""
+        for provider in self.providers:
+            await provider.close()
+
+    async def get_prices(self, symbols: List[str]) -> Dict[str, MarketPrice]:
+        """Prices for the given symbols; symbols no provider knows are omitted"""
+        symbols = list(dict.fromkeys(symbol.upper() for symbol in symbols))
+        prices: Dict[str, MarketPrice] = {}
+
+        for symbol in symbols:
+            cached = await self.cache.get_key(f"{self._cache_key_prefix}{symbol}")
+            if cached:
+                prices[symbol] = cached
+
+        for provider in self.providers:
+            missing = [symbol for symbol in symbols if symbol not in prices]
+            if not missing:
+                break
+            try:
+                fetched = await provider.get_prices(missing)
+            except Exception as e:
+                logger.error(f"Price provider {provider.name} failed: {e}")
+                continue
+            for symbol, price in fetched.items():
+                prices[symbol] = price
+                await self.cache.set_key(f"{self._cache_key_prefix}{symbol}", price, expiry=env.PRICE_CACHE_TTL)
+
+        return prices
+
+    async def get_price(self, symbol: str) -> Optional[MarketPrice]:
+        """Price for a single symbol, or None"""
+        return (await self.get_prices([symbol])).get(symbol.upper())
+
+# Create singleton instance
+market_data_service = MarketDataService()
@@ -0,0 +1,168 @@
+from typing import Any, Dict, List, Optional, Set
+from loguru import logger
+import aiohttp
+import asyncio
+import itertools
+
+from env import env
+from app.core.http_client import create_aiohttp_session
+
+class MarketSubscriber:
+    """One client connection's symbol set and bounded outgoing queue"""
+    QUEUE_SIZE = 200
+
+    def __init__(self):
+        self.symbols: Set[str] = set()
+        self.queue: asyncio.Queue = asyncio.Queue(maxsize=self.QUEUE_SIZE)
+        self.dropped = 0
+
+    def offer(self, message: Dict[str, Any]):
+        """Queue an update, dropping the oldest one if the client can't keep up"""
+        if self.queue.full():
+            self.queue.get_nowait()
+            self.dropped += 1
+        self.queue.put_nowait(message)
+
+class MarketStreamService:
+    """Fans Binance ticker and trade streams out to WebSocket clients.
+
+    A single upstream connection carries the union of all client
+    subscriptions; it reconnects with backoff and resubscribes on failure.
+    """
+    _instance: Optional['MarketStreamService'] = None
+    _initialized: bool = False
+    QUOTE_ASSET = "USDT"
+    MAX_BACKOFF = 30
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(MarketStreamService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._subscribers: Set[MarketSubscriber] = set()
+            self._refcounts: Dict[str, int] = {}
+            self._ws: Optional[aiohttp.ClientWebSocketResponse] = None
+            self._task: Optional[asyncio.Task] = None
+            self._request_ids = itertools.count(1)
+            self._initialized = True
+
+    def _streams(self, symbols) -> List[str]:
+        pair = lambda symbol: f"{symbol}{self.QUOTE_ASSET}".lower()
+        return [f"{pair(s)}@{kind}" for s in symbols for kind in ("ticker", "trade")]
+
+    async def _send_subscription(self, method: str, symbols):
+        """Tell the upstream connection about changed symbols, if it is up"""
+        if self._ws is None or self._ws.closed or not symbols:
+            return
+        try:
+            await self._ws.send_json({"method": method, "params": self._streams(symbols), "id": next(self._request_ids)})
+        except Exception as e:
+            # The reconnect loop resubscribes everything
+            logger.warning(f"Market stream {method} failed: {e}")
+
+    def connect(self) -> MarketSubscriber:
+        subscriber = MarketSubscriber()
+        self._subscribers.add(subscriber)
+        if self._task is None or self._task.done():
+            self._task = asyncio.create_task(self._run())
+        return subscriber
+
+    async def disconnect(self, subscriber: MarketSubscriber):
+        await self.unsubscribe(subscriber, list(subscriber.symbols))
+        self._subscribers.discard(subscriber)
+        if not self._subscribers and self._task:
+            # Nobody is listening; drop the upstream connection
+            self._task.cancel()
+            self._task = None
+
+    async def subscribe(self, subscriber: MarketSubscriber, symbols: List[str]):
+        added = []
+        for symbol in {s.upper() for s in symbols} - subscriber.symbols:
+            subscriber.symbols.add(symbol)
+            self._refcounts[symbol] = self._refcounts.get(symbol, 0) + 1
+            if self._refcounts[symbol] == 1:
+                added.append(symbol)
+        await self._send_subscription("SUBSCRIBE", added)
+
+    async def unsubscribe(self, subscriber: MarketSubscriber, symbols: List[str]):
+        removed = []
+        for symbol in {s.upper() for s in symbols} & subscriber.symbols:
+            subscriber.symbols.discard(symbol)
+            self._refcounts[symbol] -= 1
+            if self._refcounts[symbol] == 0:
+                del self._refcounts[symbol]
+                removed.append(symbol)
+        await self._send_subscription("UNSUBSCRIBE", removed)
+
+    def _normalize(self, data: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+        """Convert a Binance stream payload to the client message format"""
+        pair = data.get("s", "")
+        if not pair.endswith(self.QUOTE_ASSET):
+            return None
+        symbol = pair[:-len(self.QUOTE_ASSET)]
+        if data.get("e") == "24hrTicker":
+            return {
+                "type": "ticker",
+                "symbol": symbol,
+                "price": float(data["c"]),
+                "change_24h": float(data["P"]),
+                "volume_24h": float(data["q"]),
+                "timestamp": data["E"],
+            }
+        if data.get("e") == "trade":
+            return {
+                "type": "trade",
+                "symbol": symbol,
+                "price": float(data["p"]),
+                "quantity": float(data["q"]),
+                # The buyer being the maker means the aggressor sold
+                "side": "sell" if data.get("m") else "buy",
+                "timestamp": data["T"],
+            }
+        return None
+
+    def _dispatch(self, message: Dict[str, Any]):
+        for subscriber in list(self._subscribers):
+            if message["symbol"] in subscriber.symbols:
+                subscriber.offer(message)
+
+    async def _run(self):
+        """Keep the upstream connection alive while there are subscribers"""
+        backoff = 1
+        async with create_aiohttp_session() as session:
+            while self._subscribers:
+                try:
+                    async with session.ws_connect(env.BINANCE_WS_URL, heartbeat=30) as ws:
+                        self._ws = ws
+                        backoff = 1
+                        logger.info("Market stream connected")
+                        await self._send_subscription("SUBSCRIBE", list(self._refcounts))
+                        async for msg in ws:
+                            if msg.type != aiohttp.WSMsgType.TEXT:
+                                continue
+                            message = self._normalize(msg.json())
+                            if message:
+                                self._dispatch(message)
+                except asyncio.CancelledError:
+                    raise
+                except Exception as e:
+                    logger.warning(f"Market stream disconnected: {e}")
+                finally:
+                    self._ws = None
+                await asyncio.sleep(backoff)
+                backoff = min(backoff * 2, self.MAX_BACKOFF)
+
+    async def close(self):
+        """Stop the upstream connection"""
+        if self._task:
+            self._task.cancel()
+            try:
+                await self._task
+            except asyncio.CancelledError:
+                pass
+            self._task = None
+
+# Create singleton instance
+market_stream_service = MarketStreamService()
@@ -7,6 +7,7 @@ import random
 import json
 
 from env import env
+from app.core.http_client import create_aiohttp_session
 from app.models.schemas import NewsItem
 from app.services.cache_service import CacheService
 
@@ -38,7 +39,7 @@ class NewsService:
     async def initialize(self):
         """Initialize the HTTP session"""
         if not self.session:
-            self.session = aiohttp.ClientSession()
+            self.session = create_aiohttp_session()
 
     async def close(self):
         """Close the HTTP session"""
@@ -1,7 +1,173 @@
-from typing import Optional, Any
+from typing import Any, Dict, List, Optional, Tuple
+from dataclasses import dataclass, field
+from email.message import EmailMessage
+from string import Template
+from urllib.parse import urlsplit
 from loguru import logger
+import asyncio
+import fnmatch
+import ipaddress
+import smtplib
+import httpx
+
+from prisma import Json
+
+from env import env
+from app.core.http_client import create_client
+from app.core.db import db
+
+# Default templates per event type; NOTIFICATION_TEMPLATES overrides them.
+# Placeholders use string.Template syntax ($name or ${name}).
+DEFAULT_TEMPLATES: Dict[str, Dict[str, str]] = {
+    "alerts.triggered": {
+        "title": "$symbol price alert",
+        "body": (
+            "$direction_emoji PRICE ALERT $direction_emoji\n\n"
+            "• $symbol is now $condition_upper your target price!\n\n"
+            "🎯 Target: $$$target_price ($condition)\n"
+            "💰 Current: $$$current_price ($price_diff%)$stats\n\n"
+            "🔔 Use /alerts to manage your alerts"
+        ),
+    },
+    "arbitrage.opportunity": {
+        "title": "$symbol arbitrage opportunity",
+        "body": (
+            "⚖️ Arbitrage: $symbol\n"
+            "Buy on $buy_source at $$$buy_price\n"
+            "Sell on $sell_source at $$$sell_price\n"
+            "Spread: $gross_spread_pct% ($net_spread_pct% after fees)"
+        ),
+    },
+    "notifications.test": {
+        "title": "Test notification",
+        "body": "✅ Notifications are delivered to this channel.",
+    },
+}
+FALLBACK_TEMPLATE = {"title": "$event", "body": "$event"}
+DEFAULT_ROUTE = ["telegram"]
+# Channels that need a destination in the user's settings (Telegram uses their chat)
+DESTINATION_CHANNELS = ("email", "discord", "slack", "webhook")
+
+@dataclass
+class Notification:
+    event: str
+    title: str
+    body: str
+    data: Dict[str, Any] = field(default_factory=dict)
+
+class Channel:
+    """A way of delivering notifications; destinations are channel-specific"""
+    name = "channel"
+
+    async def send(self, destination: str, notification: Notification):
+        raise NotImplementedError
+
+class TelegramChannel(Channel):
+    name = "telegram"
+
+    def __init__(self, service: 'NotificationService'):
+        self.service = service
+
+    async def send(self, destination: str, notification: Notification):
+        if not await self.service.send_message(int(destination), notification.body):
+            raise RuntimeError("Telegram delivery failed")
+
+class EmailChannel(Channel):
+    """SMTP delivery; smtplib blocks, so sends run in a worker thread"""
+    name = "email"
+
+    def _send_sync(self, message: EmailMessage):
+        with smtplib.SMTP(env.SMTP_HOST, env.SMTP_PORT, timeout=10) as smtp:
+            if env.SMTP_STARTTLS:
+                smtp.starttls()
+            if env.SMTP_USERNAME:
+                smtp.login(env.SMTP_USERNAME, env.SMTP_PASSWORD)
+            smtp.send_message(message)
+
+    async def send(self, destination: str, notification: Notification):
+        if not env.SMTP_HOST:
+            raise RuntimeError("SMTP is not configured")
+        message = EmailMessage()
+        message["From"] = env.SMTP_FROM
+        message["To"] = destination
+        message["Subject"] = notification.title
+        message.set_content(notification.body)
+        await asyncio.to_thread(self._send_sync, message)
+
+class HttpChannel(Channel):
+    """Channels that POST JSON to a (webhook) URL.
+
+    Destinations are user-supplied, so every URL is checked before each
+    send, not just when it is saved: official hosts only for Discord and
+    Slack, and allowlisted hosts with public addresses for generic webhooks.
+    """
+    URL_PREFIXES: Tuple[str, ...] = ()
+
+    def __init__(self, client: httpx.AsyncClient):
+        self.client = client
+
+    def payload(self, notification: Notification) -> Dict[str, Any]:
+        raise NotImplementedError
+
+    async def check_destination(self, destination: str):
+        """Raises ValueError if the URL may not receive notifications"""
+        if not destination.startswith(self.URL_PREFIXES):
+            raise ValueError(f"The {self.name} destination must start with {' or '.join(self.URL_PREFIXES)}")
+
+    async def send(self, destination: str, notification: Notification):
+        await self.check_destination(destination)
+        response = await self.client.post(destination, json=self.payload(notification), follow_redirects=False)
+        response.raise_for_status()
+
+class DiscordChannel(HttpChannel):
+    name = "discord"
+    URL_PREFIXES = ("https://discord.com/api/webhooks/", "https://discordapp.com/api/webhooks/")
+
+    def payload(self, notification: Notification) -> Dict[str, Any]:
+        return {"content": f"**{notification.title}**\n{notification.body}"}
+
+class SlackChannel(HttpChannel):
+    name = "slack"
+    URL_PREFIXES = ("https://hooks.slack.com/",)
+
+    def payload(self, notification: Notification) -> Dict[str, Any]:
+        return {"text": f"*{notification.title}*\n{notification.body}"}
+
+class WebhookChannel(HttpChannel):
+    name = "webhook"
+    URL_PREFIXES = ("https://",)
+
+    async def check_destination(self, destination: str):
+        await super().check_destination(destination)
+        host = urlsplit(destination).hostname or ""
+        if not any(fnmatch.fnmatchcase(host, pattern) for pattern in env.NOTIFICATION_WEBHOOK_HOSTS):
+            raise ValueError(f"Webhook host {host} is not in NOTIFICATION_WEBHOOK_HOSTS")
+        # Allowlisted names must still not point into the private network
+        try:
+            infos = await asyncio.get_running_loop().getaddrinfo(host, urlsplit(destination).port or 443)
+        except OSError as e:
+            raise ValueError(f"Cannot resolve webhook host {host}: {e}")
+        for info in infos:
+            address = ipaddress.ip_address(info[4][0])
+            if not address.is_global:
+                raise ValueError(f"Webhook host {host} resolves to non-public address {address}")
+
+    def payload(self, notification: Notification) -> Dict[str, Any]:
+        return {
+            "event": notification.event,
+            "title": notification.title,
+            "body": notification.body,
+            "data": notification.data,
+        }
 
 class NotificationService:
+    """Renders event notifications and routes them to delivery channels.
+
+    Users choose channels per event type in their notificationSettings:
+    {"destinations": {"email": "...", "slack": "<webhook URL>", ...},
+     "routes": {"alerts.triggered": ["telegram", "email"], "*": ["telegram"]}}.
+    Telegram always delivers to the user's own chat.
+    """
     _instance: Optional['NotificationService'] = None
     _initialized: bool = False
 
@@ -13,13 +179,27 @@ class NotificationService:
     def __init__(self):
         if not self._initialized:
             self._bot = None
+            self.client = create_client()
+            self.channels: Dict[str, Channel] = {
+                channel.name: channel for channel in (
+                    TelegramChannel(self),
+                    EmailChannel(),
+                    DiscordChannel(self.client),
+                    SlackChannel(self.client),
+                    WebhookChannel(self.client),
+                )
+            }
             self._initialized = True
 
     def set_bot(self, bot: Any):
         """Set the bot instance for sending messages"""
         self._bot = bot
 
-    async def send_message(self, chat_id: int, text: str) -> bool:
+    async def close(self):
+        """Close HTTP client"""
+        await self.client.aclose()
+
+    async def send_message(self, chat_id: int, text: str, parse_mode: Optional[str] = None) -> bool:
         """Send a message to a specific chat"""
         try:
             if not self._bot:
@@ -28,12 +208,95 @@ class NotificationService:
 
             await self._bot.send_message(
                 chat_id=chat_id,
-                text=text
+                text=text,
+                parse_mode=parse_mode
             )
             return True
         except Exception as e:
             logger.error(f"Error sending notification: {e}")
             return False
 
+    def render(self, event: str, data: Dict[str, Any]) -> Notification:
+        """Fill the event's template; unknown placeholders are left as-is"""
+        template = {**FALLBACK_TEMPLATE, **DEFAULT_TEMPLATES.get(event, {}), **env.NOTIFICATION_TEMPLATES.get(event, {})}
+        values = {"event": event, **data}
+        return Notification(
+            event=event,
+            title=Template(template["title"]).safe_substitute(values),
+            body=Template(template["body"]).safe_substitute(values),
+            data=data,
+        )
+
+    async def validate_settings(self, settings: Dict[str, Any]):
+        """Check a user's routing preferences. Raises ValueError if invalid"""
+        for channel, destination in settings.get("destinations", {}).items():
+            if channel not in DESTINATION_CHANNELS:
+                raise ValueError(f"Unknown destination channel {channel}")
+            if isinstance(self.channels[channel], HttpChannel):
+                await self.channels[channel].check_destination(str(destination))
+        for event, route in settings.get("routes", {}).items():
+            unknown = set(route) - {"telegram", *DESTINATION_CHANNELS}
+            if unknown:
+                raise ValueError(f"Unknown channels for {event}: {', '.join(sorted(unknown))}")
+
+    async def get_settings(self, telegram_id: int) -> Dict[str, Any]:
+        user = await db.prisma.user.find_unique(where={"telegramId": telegram_id})
+        return dict(user.notificationSettings or {}) if user else {}
+
+    async def update_settings(self, telegram_id: int, settings: Dict[str, Any]) -> Dict[str, Any]:
+        """Store a user's routing preferences, creating the user on first use"""
+        await self.validate_settings(settings)
+        user = await db.prisma.user.upsert(
+            where={"telegramId": telegram_id},
+            data={
+                "create": {"telegramId": telegram_id, "notificationSettings": Json(settings)},
+                "update": {"notificationSettings": Json(settings)},
+            }
+        )
+        return dict(user.notificationSettings or {})
+
+    async def dispatch(self, event: str, data: Dict[str, Any], destinations: List[Tuple[str, str]]) -> List[str]:
+        """Deliver an event to explicit (channel, destination) pairs; returns the channels that succeeded"""
+        notification = self.render(event, data)
+
+        async def deliver(channel_name: str, destination: str) -> Optional[str]:
+            channel = self.channels.get(channel_name)
+            if not channel:
+                logger.warning(f"Unknown notification channel {channel_name}")
+                return None
+            try:
+                await channel.send(destination, notification)
+                return channel_name
+            except Exception as e:
+                logger.error(f"{channel_name} notification for {event} failed: {e}")
+                return None
+
+        results = await asyncio.gather(*(deliver(c, d) for c, d in destinations))
+        return [channel for channel in results if channel]
+
+    async def notify(self, telegram_id: int, event: str, data: Dict[str, Any]) -> List[str]:
+        """Deliver an event to a user over the channels they chose for it"""
+        try:
+            settings = await self.get_settings(telegram_id)
+        except Exception as e:
+            logger.error(f"Error loading notification settings for {telegram_id}: {e}")
+            settings = {}
+        routes = settings.get("routes", {})
+        route = routes.get(event) or routes.get("*") or DEFAULT_ROUTE
+        destinations = settings.get("destinations", {})
+
+        targets = []
+        for channel in route:
+            if channel == "telegram":
+                targets.append((channel, str(telegram_id)))
+            elif destinations.get(channel):
+                targets.append((channel, destinations[channel]))
+        return await self.dispatch(event, data, targets)
+
+    async def notify_operators(self, event: str, data: Dict[str, Any], extra: List[Tuple[str, str]] = ()) -> List[str]:
+        """Deliver a system event to the destinations in NOTIFICATION_ROUTES"""
+        targets = [(route["channel"], route["to"]) for route in env.NOTIFICATION_ROUTES.get(event, [])]
+        return await self.dispatch(event, data, [*targets, *extra])
+
 # Create singleton instance
-notification_service = NotificationService() 
\ No newline at end of file
+notification_service = NotificationService()
@@ -0,0 +1,151 @@
+from typing import Any, Dict, List, Optional
+from urllib.parse import urlencode
+from loguru import logger
+from jose import jwt, JWTError
+import base64
+import hashlib
+import httpx
+import secrets
+
+from env import env
+from app.core.http_client import create_client
+from app.services.cache_service import CacheService
+
+class OIDCError(Exception):
+    """Raised when an OpenID Connect login cannot be completed"""
+
+class OIDCService:
+    _instance: Optional['OIDCService'] = None
+    _initialized: bool = False
+    DISCOVERY_CACHE_TTL = 3600  # Re-read the provider metadata hourly
+    LOGIN_STATE_TTL = 600  # Users have 10 minutes to complete a login
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(OIDCService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.cache = CacheService()
+            self.client = create_client()
+            self._state_key_prefix = "oidc_state:"
+            self._initialized = True
+
+    @property
+    def enabled(self) -> bool:
+        return bool(env.OIDC_ISSUER)
+
+    async def close(self):
+        """Close HTTP client"""
+        await self.client.aclose()
+
+    async def _get_json(self, url: str, cache_key: str) -> Dict[str, Any]:
+        """Fetch a JSON document from the provider with caching"""
+        cached = await self.cache.get_key(cache_key)
+        if cached:
+            return cached
+
+        response = await self.client.get(url)
+        response.raise_for_status()
+        data = response.json()
+        await self.cache.set_key(cache_key, data, expiry=self.DISCOVERY_CACHE_TTL)
+        return data
+
+    async def _metadata(self) -> Dict[str, Any]:
+        return await self._get_json(
+            f"{env.OIDC_ISSUER}/.well-known/openid-configuration",
+            "oidc_metadata"
+        )
+
+    async def _jwks(self) -> Dict[str, Any]:
+        metadata = await self._metadata()
+        return await self._get_json(metadata["jwks_uri"], "oidc_jwks")
+
+    async def authorization_url(self) -> str:
+        """Start a login: remember state, nonce and PKCE verifier, return the IdP URL"""
+        metadata = await self._metadata()
+        state = secrets.token_urlsafe(32)
+        nonce = secrets.token_urlsafe(32)
+        code_verifier = secrets.token_urlsafe(64)
+        code_challenge = base64.urlsafe_b64encode(
+            hashlib.sha256(code_verifier.encode()).digest()
+        ).rstrip(b"=").decode()
+
+        await self.cache.set_key(
+            f"{self._state_key_prefix}{state}",
+            {"nonce": nonce, "code_verifier": code_verifier},
+            expiry=self.LOGIN_STATE_TTL
+        )
+
+        params = {
+            "response_type": "code",
+            "client_id": env.OIDC_CLIENT_ID,
+            "redirect_uri": env.OIDC_REDIRECT_URI,
+            "scope": env.OIDC_SCOPES,
+            "state": state,
+            "nonce": nonce,
+            "code_challenge": code_challenge,
+            "code_challenge_method": "S256",
+        }
+        return f"{metadata['authorization_endpoint']}?{urlencode(params)}"
+
+    async def complete_login(self, code: str, state: str) -> Dict[str, Any]:
+        """Exchange the authorization code and return the verified ID token claims"""
+        state_key = f"{self._state_key_prefix}{state}"
+        login = await self.cache.get_key(state_key)
+        if not login:
+            raise OIDCError("Unknown or expired login state")
+        # State is single-use
+        await self.cache.delete_key(state_key)
+
+        metadata = await self._metadata()
+        try:
+            response = await self.client.post(
+                metadata["token_endpoint"],
+                data={
+                    "grant_type": "authorization_code",
+                    "code": code,
+                    "redirect_uri": env.OIDC_REDIRECT_URI,
+                    "client_id": env.OIDC_CLIENT_ID,
+                    "client_secret": env.OIDC_CLIENT_SECRET,
+                    "code_verifier": login["code_verifier"],
+                }
+            )
+            response.raise_for_status()
+            tokens = response.json()
+        except httpx.HTTPError as e:
+            logger.error(f"OIDC code exchange failed: {e}")
+            raise OIDCError("Code exchange with the identity provider failed")
+
+        id_token = tokens.get("id_token")
+        if not id_token:
+            raise OIDCError("Identity provider did not return an ID token")
+
+        try:
+            claims = jwt.decode(
+                id_token,
+                await self._jwks(),
+                algorithms=metadata.get("id_token_signing_alg_values_supported", ["RS256"]),
+                audience=env.OIDC_CLIENT_ID,
+                issuer=metadata.get("issuer", env.OIDC_ISSUER),
+                access_token=tokens.get("access_token"),
+            )
+        except JWTError as e:
+            logger.warning(f"OIDC ID token rejected: {e}")
+            raise OIDCError("Invalid ID token")
+
+        if claims.get("nonce") != login["nonce"]:
+            raise OIDCError("ID token nonce mismatch")
+        return claims
+
+    def roles_for(self, claims: Dict[str, Any]) -> List[str]:
+        """Map the IdP groups claim to bot roles via OIDC_GROUP_ROLES"""
+        groups = claims.get(env.OIDC_GROUPS_CLAIM) or []
+        if isinstance(groups, str):
+            groups = [groups]
+        roles = {env.OIDC_GROUP_ROLES[group] for group in groups if group in env.OIDC_GROUP_ROLES}
+        return sorted(f"role:{role}" for role in roles)
+
+# Create singleton instance
+oidc_service = OIDCService()
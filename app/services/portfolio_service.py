@@ -0,0 +1,226 @@
+from typing import Any, Dict, List, Optional
+from datetime import datetime
+from loguru import logger
+import asyncio
+import re
+
+from prisma import Json
+
+from env import env
+from app.core.db import db
+from app.models.schemas import Holding, PortfolioSnapshotInfo, PortfolioValuation, WalletInfo
+from app.services.chain_registry import EVM, chain_registry
+from app.services.market_data_service import market_data_service
+
+_EVM_ADDRESS = re.compile(r"^0x[0-9a-fA-F]{40}$")
+
+class PortfolioService:
+    """Manual positions plus watched wallets, valued through the shared price feed"""
+    _instance: Optional['PortfolioService'] = None
+    _initialized: bool = False
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(PortfolioService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._running = False
+            self._task: Optional[asyncio.Task] = None
+            self._initialized = True
+
+    async def close(self):
+        """Stop snapshots"""
+        await self.stop_snapshots()
+
+    async def _user_id(self, telegram_id: int) -> str:
+        """Internal user ID for a Telegram user, creating the user on first use"""
+        user = await db.prisma.user.upsert(
+            where={"telegramId": telegram_id},
+            data={"create": {"telegramId": telegram_id}, "update": {}}
+        )
+        return user.id
+
+    async def add_position(self, telegram_id: int, symbol: str, quantity: float, price: float):
+        """Add to a position, keeping a quantity-weighted average entry price"""
+        user_id = await self._user_id(telegram_id)
+        symbol = symbol.upper()
+        existing = await db.prisma.portfolio.find_unique(
+            where={"userId_symbol": {"userId": user_id, "symbol": symbol}}
+        )
+        if existing:
+            total = existing.quantity + quantity
+            average = (existing.quantity * existing.averagePrice + quantity * price) / total
+            return await db.prisma.portfolio.update(
+                where={"id": existing.id},
+                data={"quantity": total, "averagePrice": average}
+            )
+        return await db.prisma.portfolio.create(
+            data={"userId": user_id, "symbol": symbol, "quantity": quantity, "averagePrice": price}
+        )
+
+    async def remove_position(self, telegram_id: int, symbol: str, quantity: Optional[float] = None) -> bool:
+        """Reduce a position, or close it when quantity is omitted or covers it all"""
+        user_id = await self._user_id(telegram_id)
+        existing = await db.prisma.portfolio.find_unique(
+            where={"userId_symbol": {"userId": user_id, "symbol": symbol.upper()}}
+        )
+        if not existing:
+            return False
+        if quantity is None or quantity >= existing.quantity:
+            await db.prisma.portfolio.delete(where={"id": existing.id})
+        else:
+            await db.prisma.portfolio.update(
+                where={"id": existing.id},
+                data={"quantity": existing.quantity - quantity}
+            )
+        return True
+
+    async def add_wallet(self, telegram_id: int, chain: str, address: str, label: Optional[str] = None) -> WalletInfo:
+        """Watch an on-chain wallet. Raises ValueError for unsupported chains or bad addresses"""
+        chain = chain_registry.get(chain, kind=EVM).name
+        if not _EVM_ADDRESS.match(address):
+            raise ValueError("Invalid wallet address")
+        user_id = await self._user_id(telegram_id)
+        wallet = await db.prisma.wallet.upsert(
+            where={"userId_chain_address": {"userId": user_id, "chain": chain, "address": address.lower()}},
+            data={
+                "create": {"userId": user_id, "chain": chain, "address": address.lower(), "label": label},
+                "update": {"label": label},
+            }
+        )
+        return WalletInfo(id=wallet.id, chain=wallet.chain, address=wallet.address, label=wallet.label)
+
+    async def list_wallets(self, telegram_id: int) -> List[WalletInfo]:
+        user_id = await self._user_id(telegram_id)
+        wallets = await db.prisma.wallet.find_many(where={"userId": user_id})
+        return [WalletInfo(id=w.id, chain=w.chain, address=w.address, label=w.label) for w in wallets]
+
+    async def remove_wallet(self, telegram_id: int, wallet_id: str) -> bool:
+        user_id = await self._user_id(telegram_id)
+        return await db.prisma.wallet.delete_many(where={"id": wallet_id, "userId": user_id}) > 0
+
+    async def _native_balance(self, chain: str, address: str) -> Optional[float]:
+        """Native coin balance of a wallet via eth_getBalance"""
+        try:
+            result = await chain_registry.rpc(chain, "eth_getBalance", [address, "latest"])
+            return int(result, 16) / 10 ** chain_registry.get(chain).decimals if result else None
+        except Exception as e:
+            logger.error(f"Balance lookup failed for {chain}:{address}: {e}")
+            return None
+
+    async def valuation(self, telegram_id: int) -> PortfolioValuation:
+        """Current value and allocation of positions and wallet balances"""
+        user_id = await self._user_id(telegram_id)
+        positions = await db.prisma.portfolio.find_many(where={"userId": user_id})
+        wallets = await db.prisma.wallet.find_many(where={"userId": user_id})
+
+        holdings = [
+            Holding(
+                symbol=p.symbol,
+                quantity=p.quantity,
+                cost_basis_usd=p.quantity * p.averagePrice,
+                source="position",
+            )
+            for p in positions
+        ]
+        balances = await asyncio.gather(*(self._native_balance(w.chain, w.address) for w in wallets))
+        for wallet, balance in zip(wallets, balances):
+            if balance:
+                holdings.append(Holding(
+                    symbol=chain_registry.get(wallet.chain).native_symbol,
+                    quantity=balance,
+                    source=f"wallet:{wallet.chain}:{wallet.address}",
+                ))
+
+        prices = await market_data_service.get_prices([h.symbol for h in holdings])
+        for holding in holdings:
+            price = prices.get(holding.symbol)
+            if price:
+                holding.price_usd = price.price_usd
+                holding.value_usd = holding.quantity * price.price_usd
+
+        total = sum(h.value_usd or 0 for h in holdings)
+        for holding in holdings:
+            if holding.value_usd is not None and total:
+                holding.allocation_pct = round(holding.value_usd / total * 100, 2)
+
+        cost_basis = sum(h.cost_basis_usd or 0 for h in holdings)
+        # P&L only covers positions with a known entry price
+        position_value = sum(h.value_usd or 0 for h in holdings if h.source == "position")
+        return PortfolioValuation(
+            total_value_usd=total,
+            cost_basis_usd=cost_basis,
+            unrealized_pnl_usd=position_value - cost_basis,
+            holdings=holdings,
+            unpriced=sorted({h.symbol for h in holdings if h.price_usd is None}),
+        )
+
+    async def snapshot(self, telegram_id: int) -> PortfolioValuation:
+        """Value a portfolio and store the result"""
+        valuation = await self.valuation(telegram_id)
+        await db.prisma.portfoliosnapshot.create(
+            data={
+                "userId": await self._user_id(telegram_id),
+                "totalValue": valuation.total_value_usd,
+                "holdings": Json([h.model_dump() for h in valuation.holdings]),
+            }
+        )
+        return valuation
+
+    async def history(self, telegram_id: int, since: Optional[datetime] = None, limit: int = 100) -> List[PortfolioSnapshotInfo]:
+        """Stored snapshots, newest first"""
+        user_id = await self._user_id(telegram_id)
+        where: Dict[str, Any] = {"userId": user_id}
+        if since:
+            where["takenAt"] = {"gte": since}
+        snapshots = await db.prisma.portfoliosnapshot.find_many(where=where, order={"takenAt": "desc"}, take=limit)
+        return [
+            PortfolioSnapshotInfo(
+                total_value_usd=s.totalValue,
+                holdings=s.holdings,
+                taken_at=s.takenAt,
+            )
+            for s in snapshots
+        ]
+
+    async def start_snapshots(self):
+        """Start the periodic snapshot loop"""
+        if self._running:
+            return
+        self._running = True
+        self._task = asyncio.create_task(self._snapshot_loop())
+        logger.info("Portfolio snapshots started")
+
+    async def stop_snapshots(self):
+        """Stop the periodic snapshot loop"""
+        if not self._running:
+            return
+        self._running = False
+        if self._task:
+            self._task.cancel()
+            try:
+                await self._task
+            except asyncio.CancelledError:
+                pass
+        logger.info("Portfolio snapshots stopped")
+
+    async def _snapshot_loop(self):
+        """Snapshot every user that has positions or wallets"""
+        while self._running:
+            try:
+                users = await db.prisma.user.find_many(
+                    where={"OR": [{"portfolio": {"some": {}}}, {"wallets": {"some": {}}}]}
+                )
+                for user in users:
+                    try:
+                        await self.snapshot(user.telegramId)
+                    except Exception as e:
+                        logger.error(f"Portfolio snapshot failed for {user.id}: {e}")
+            except Exception as e:
+                logger.error(f"Error in portfolio snapshot loop: {e}")
+            await asyncio.sleep(env.PORTFOLIO_SNAPSHOT_INTERVAL)
+
+# Create singleton instance
+portfolio_service = PortfolioService()
@@ -5,6 +5,7 @@ from loguru import logger
 from datetime import datetime, timedelta
 
 from env import env
+from app.core.http_client import create_aiohttp_session
 
 class PriceService:
     def __init__(self):
@@ -14,7 +15,7 @@ class PriceService:
     async def initialize(self):
         """Initialize the HTTP session"""
         if not self.session:
-            self.session = aiohttp.ClientSession()
+            self.session = create_aiohttp_session()
 
     async def close(self):
         """Close the HTTP session"""
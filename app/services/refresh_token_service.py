@@ -0,0 +1,131 @@
+from typing import List, Optional, Tuple
+from loguru import logger
+from datetime import datetime, timedelta, timezone
+import hashlib
+import secrets
+import uuid
+
+from env import env
+from app.core.db import db
+from app.services.event_bus import event_bus
+
+class RefreshTokenReuseError(Exception):
+    """Raised when an already-rotated refresh token is presented again"""
+
+class RefreshTokenService:
+    _instance: Optional['RefreshTokenService'] = None
+    _initialized: bool = False
+    TOKEN_BYTES = 48
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(RefreshTokenService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self._initialized = True
+
+    @staticmethod
+    def _hash(raw_token: str) -> str:
+        return hashlib.sha256(raw_token.encode()).hexdigest()
+
+    async def issue(
+        self,
+        subject: str,
+        scopes: List[str],
+        credential_hash: Optional[str] = None,
+        family_id: Optional[str] = None,
+    ) -> str:
+        """Issue a refresh token, starting a new family unless one is given.
+
+        credential_hash fingerprints the credential the session came from, so
+        rotating or revoking that credential ends the session too.
+        """
+        try:
+            raw_token = secrets.token_urlsafe(self.TOKEN_BYTES)
+            await db.prisma.refreshtoken.create(
+                data={
+                    "familyId": family_id or str(uuid.uuid4()),
+                    "tokenHash": self._hash(raw_token),
+                    "subject": subject,
+                    "scopes": scopes,
+                    "credentialHash": credential_hash,
+                    "expiresAt": datetime.now(timezone.utc) + timedelta(seconds=env.JWT_REFRESH_TOKEN_TTL),
+                }
+            )
+            return raw_token
+        except Exception as e:
+            logger.error(f"Error issuing refresh token for {subject}: {e}")
+            raise
+
+    async def rotate(self, raw_token: str) -> Optional[Tuple[str, str, List[str], Optional[str]]]:
+        """Exchange a refresh token for a new one in the same family.
+
+        Returns (new_token, subject, scopes, credential_hash), or None if the token is unknown,
+        expired or revoked. Presenting a token that was already rotated revokes
+        the whole family and raises RefreshTokenReuseError.
+        """
+        record = await db.prisma.refreshtoken.find_unique(
+            where={"tokenHash": self._hash(raw_token)}
+        )
+        if not record or record.revokedAt is not None:
+            return None
+
+        if record.usedAt is not None:
+            logger.warning(f"Refresh token reuse detected for {record.subject}, revoking family {record.familyId}")
+            await self.revoke_family(record.familyId)
+            event_bus.publish("auth.refresh_token_reused", {"subject": record.subject, "family_id": record.familyId})
+            raise RefreshTokenReuseError()
+
+        now = datetime.now(timezone.utc)
+        if record.expiresAt < now:
+            return None
+
+        # Mark as used only if nobody else rotated it concurrently
+        updated = await db.prisma.refreshtoken.update_many(
+            where={"id": record.id, "usedAt": None},
+            data={"usedAt": now}
+        )
+        if updated == 0:
+            await self.revoke_family(record.familyId)
+            raise RefreshTokenReuseError()
+
+        scopes = list(record.scopes or [])
+        new_token = await self.issue(record.subject, scopes, record.credentialHash, family_id=record.familyId)
+        return new_token, record.subject, scopes, record.credentialHash
+
+    async def find_active(self, raw_token: str):
+        """Return the stored record for a usable refresh token, or None"""
+        record = await db.prisma.refreshtoken.find_unique(
+            where={"tokenHash": self._hash(raw_token)}
+        )
+        if not record or record.revokedAt is not None or record.usedAt is not None:
+            return None
+        if record.expiresAt < datetime.now(timezone.utc):
+            return None
+        return record
+
+    async def revoke(self, raw_token: str) -> bool:
+        """Revoke the family a refresh token belongs to, returns False if unknown"""
+        record = await db.prisma.refreshtoken.find_unique(
+            where={"tokenHash": self._hash(raw_token)}
+        )
+        if not record:
+            return False
+        await self.revoke_family(record.familyId)
+        return True
+
+    async def revoke_family(self, family_id: str):
+        """Revoke every token in a refresh token family"""
+        try:
+            await db.prisma.refreshtoken.update_many(
+                where={"familyId": family_id, "revokedAt": None},
+                data={"revokedAt": datetime.now(timezone.utc)}
+            )
+        except Exception as e:
+            logger.error(f"Error revoking refresh token family {family_id}: {e}")
+            raise
+
+# Create singleton instance
+refresh_token_service = RefreshTokenService()
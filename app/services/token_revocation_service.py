@@ -0,0 +1,78 @@
+from typing import Optional
+from loguru import logger
+from datetime import datetime, timezone
+
+from app.core.db import db
+from app.services.cache_service import CacheService
+from app.services.event_bus import event_bus
+
+class TokenRevocationService:
+    _instance: Optional['TokenRevocationService'] = None
+    _initialized: bool = False
+    NOT_REVOKED_CACHE_TTL = 30  # Seconds to remember a negative lookup
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(TokenRevocationService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.cache = CacheService()
+            self._cache_key_prefix = "revoked_jti:"
+            self._initialized = True
+
+    async def revoke(self, jti: str, subject: str, expires_at: int):
+        """Add an access token to the revocation list until it expires"""
+        try:
+            expiry = datetime.fromtimestamp(expires_at, tz=timezone.utc)
+            await db.prisma.revokedtoken.upsert(
+                where={"jti": jti},
+                data={
+                    "create": {"jti": jti, "subject": subject, "expiresAt": expiry},
+                    "update": {},
+                }
+            )
+            ttl = max(1, int(expires_at - datetime.now(timezone.utc).timestamp()))
+            await self.cache.set_key(f"{self._cache_key_prefix}{jti}", True, expiry=ttl)
+            logger.info(f"Access token {jti} revoked for {subject}")
+            event_bus.publish("auth.token_revoked", {"jti": jti, "subject": subject})
+        except Exception as e:
+            logger.error(f"Error revoking token {jti}: {e}")
+            raise
+
+    async def is_revoked(self, jti: str) -> bool:
+        """Check whether an access token has been revoked"""
+        cache_key = f"{self._cache_key_prefix}{jti}"
+        cached = await self.cache.get_key(cache_key)
+        if cached is not None:
+            return cached
+
+        try:
+            record = await db.prisma.revokedtoken.find_unique(where={"jti": jti})
+        except Exception as e:
+            # Fail closed: a token we cannot check is treated as revoked
+            logger.error(f"Error checking revocation for {jti}: {e}")
+            return True
+
+        revoked = record is not None
+        if revoked:
+            # Only needed until the token would have expired anyway
+            expiry = max(1, int((record.expiresAt - datetime.now(timezone.utc)).total_seconds()))
+        else:
+            expiry = self.NOT_REVOKED_CACHE_TTL
+        await self.cache.set_key(cache_key, revoked, expiry=expiry)
+        return revoked
+
+    async def purge_expired(self) -> int:
+        """Delete revocation entries for tokens that have expired anyway"""
+        try:
+            return await db.prisma.revokedtoken.delete_many(
+                where={"expiresAt": {"lt": datetime.now(timezone.utc)}}
+            )
+        except Exception as e:
+            logger.error(f"Error purging revoked tokens: {e}")
+            return 0
+
+# Create singleton instance
+token_revocation_service = TokenRevocationService()
@@ -0,0 +1,83 @@
+from typing import Optional
+from loguru import logger
+from datetime import datetime, timezone
+import pyotp
+
+from env import env
+from app.core.db import db
+from app.services.cache_service import CacheService
+from app.services.event_bus import event_bus
+
+class TotpService:
+    _instance: Optional['TotpService'] = None
+    _initialized: bool = False
+    VALID_WINDOW = 1  # Accept one 30s step of clock drift either way
+
+    def __new__(cls):
+        if cls._instance is None:
+            cls._instance = super(TotpService, cls).__new__(cls)
+        return cls._instance
+
+    def __init__(self):
+        if not self._initialized:
+            self.cache = CacheService()
+            self._used_code_key_prefix = "totp_used:"
+            self._initialized = True
+
+    async def start_enrollment(self, subject: str) -> Optional[pyotp.TOTP]:
+        """Create (or replace a pending) TOTP secret for a subject.
+
+        An already enabled enrollment is left untouched so a stolen token
+        can't silently re-enroll; returns None in that case.
+        """
+        existing = await db.prisma.totpenrollment.find_unique(where={"subject": subject})
+        if existing and existing.enabledAt is not None:
+            return None
+
+        secret = pyotp.random_base32()
+        await db.prisma.totpenrollment.upsert(
+            where={"subject": subject},
+            data={
+                "create": {"subject": subject, "secret": secret},
+                "update": {"secret": secret},
+            }
+        )
+        return pyotp.TOTP(secret, issuer=env.TOTP_ISSUER, name=subject)
+
+    async def confirm_enrollment(self, subject: str, code: str) -> bool:
+        """Enable a pending enrollment once the user proves they can generate codes"""
+        record = await db.prisma.totpenrollment.find_unique(where={"subject": subject})
+        if not record or record.enabledAt is not None:
+            return False
+        if not await self._check(subject, record.secret, code):
+            return False
+        await db.prisma.totpenrollment.update(
+            where={"subject": subject},
+            data={"enabledAt": datetime.now(timezone.utc)}
+        )
+        logger.info(f"TOTP enabled for {subject}")
+        event_bus.publish("auth.totp_enabled", {"subject": subject})
+        return True
+
+    async def is_enrolled(self, subject: str) -> bool:
+        record = await db.prisma.totpenrollment.find_unique(where={"subject": subject})
+        return bool(record and record.enabledAt is not None)
+
+    async def verify(self, subject: str, code: str) -> bool:
+        """Verify a code for an enabled enrollment"""
+        record = await db.prisma.totpenrollment.find_unique(where={"subject": subject})
+        if not record or record.enabledAt is None:
+            return False
+        return await self._check(subject, record.secret, code)
+
+    async def _check(self, subject: str, secret: str, code: str) -> bool:
+        """Check a code, refusing to accept the same code twice"""
+        if not code or not pyotp.TOTP(secret).verify(code, valid_window=self.VALID_WINDOW):
+            return False
+        used_key = f"{self._used_code_key_prefix}{subject}:{code}"
+        # Atomic, so concurrent requests with the same code can't both pass.
+        # Codes stay valid for up to (2 * VALID_WINDOW + 1) steps
+        return await self.cache.add_key(used_key, True, expiry=30 * (2 * self.VALID_WINDOW + 1))
+
+# Create singleton instance
+totp_service = TotpService()
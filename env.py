@@ -1,7 +1,8 @@
 import os
+import json
 from pathlib import Path
 from dotenv import load_dotenv
-from typing import Optional, Any, Dict
+from typing import Optional, Any, Dict, List
 
 # Load environment variables from .env file
 load_dotenv()
@@ -13,11 +14,60 @@ class Environment:
         self.APP_DEBUG = os.getenv("APP_DEBUG", "true").lower() in ("true", "1", "t")
         self.APP_SECRET_KEY = os.getenv("APP_SECRET_KEY", "")
         self.LOG_LEVEL = os.getenv("LOG_LEVEL", "INFO")
+        self.LOG_FORMAT = os.getenv("LOG_FORMAT", "text").lower()  # "text" or "json"
+        # Per-module overrides, e.g. {"app.services.price_service": "DEBUG"}
+        self.LOG_LEVELS: Dict[str, str] = json.loads(os.getenv("LOG_LEVELS", "{}"))
 
         # Server
         self.HOST = os.getenv("HOST", "0.0.0.0")
         self.PORT = int(os.getenv("PORT", "8000"))
 
+        # Prometheus metrics: served at /metrics, or on a separate port when
+        # METRICS_PORT is set
+        self.METRICS_ENABLED = os.getenv("METRICS_ENABLED", "true").lower() in ("true", "1", "t")
+        self.METRICS_HOST = os.getenv("METRICS_HOST", "127.0.0.1")
+        self.METRICS_PORT = int(os.getenv("METRICS_PORT", "0"))
+
+        # OpenTelemetry tracing, exported via OTLP (see OTEL_EXPORTER_OTLP_ENDPOINT)
+        self.OTEL_ENABLED = os.getenv("OTEL_ENABLED", "false").lower() in ("true", "1", "t")
+        self.OTEL_SERVICE_NAME = os.getenv("OTEL_SERVICE_NAME", "wavedex-bot")
+
+        # Request hardening: maximum body size and header section size in bytes
+        self.MAX_REQUEST_BODY_BYTES = int(os.getenv("MAX_REQUEST_BODY_BYTES", str(1024 * 1024)))
+        self.MAX_HEADER_BYTES = int(os.getenv("MAX_HEADER_BYTES", str(16 * 1024)))
+
+        # Response compression and connection timeouts
+        self.GZIP_ENABLED = os.getenv("GZIP_ENABLED", "true").lower() in ("true", "1", "t")
+        self.GZIP_MIN_SIZE = int(os.getenv("GZIP_MIN_SIZE", "1024"))
+        self.GZIP_LEVEL = int(os.getenv("GZIP_LEVEL", "6"))
+        self.KEEP_ALIVE_TIMEOUT = int(os.getenv("KEEP_ALIVE_TIMEOUT", "5"))
+        self.MAX_CONCURRENT_CONNECTIONS = int(os.getenv("MAX_CONCURRENT_CONNECTIONS", "0")) or None
+
+        # Seconds to let in-flight requests and background jobs finish on shutdown
+        self.SHUTDOWN_GRACE_PERIOD = int(os.getenv("SHUTDOWN_GRACE_PERIOD", "30"))
+
+        # CORS, disabled unless allowed origins are configured (comma-separated)
+        self.CORS_ALLOWED_ORIGINS = [o.strip() for o in os.getenv("CORS_ALLOWED_ORIGINS", "").split(",") if o.strip()]
+        self.CORS_ALLOWED_METHODS = [m.strip() for m in os.getenv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE").split(",") if m.strip()]
+        self.CORS_ALLOWED_HEADERS = [h.strip() for h in os.getenv(
+            "CORS_ALLOWED_HEADERS", "Authorization,Content-Type,X-API-Key,X-TOTP-Code,X-Request-ID"
+        ).split(",") if h.strip()]
+        self.CORS_ALLOW_CREDENTIALS = os.getenv("CORS_ALLOW_CREDENTIALS", "false").lower() in ("true", "1", "t")
+        self.CORS_MAX_AGE = int(os.getenv("CORS_MAX_AGE", "600"))
+
+        # TLS: serve HTTPS directly when a certificate and key are configured.
+        # HTTPS_REDIRECT redirects plain HTTP requests (e.g. forwarded by a
+        # proxy with X-Forwarded-Proto: http) to https.
+        self.SSL_CERTFILE = os.getenv("SSL_CERTFILE", "")
+        self.SSL_KEYFILE = os.getenv("SSL_KEYFILE", "")
+        self.SSL_KEYFILE_PASSWORD = os.getenv("SSL_KEYFILE_PASSWORD") or None
+        self.SSL_CIPHERS = os.getenv("SSL_CIPHERS", "ECDHE+AESGCM:ECDHE+CHACHA20:!aNULL:!MD5:!DSS")
+        # mTLS on the listener: CA bundle for verifying client certificates, and
+        # whether connections without one are refused
+        self.TLS_CLIENT_CA = os.getenv("TLS_CLIENT_CA", "")
+        self.TLS_CLIENT_CERT_REQUIRED = os.getenv("TLS_CLIENT_CERT_REQUIRED", "false").lower() in ("true", "1", "t")
+        self.HTTPS_REDIRECT = os.getenv("HTTPS_REDIRECT", "false").lower() in ("true", "1", "t")
+
         # Database
         self.DATABASE_URL = os.getenv("DATABASE_URL", "")
 
@@ -30,6 +80,126 @@ class Environment:
         self.NEWS_API_KEY = os.getenv("NEWS_API_KEY")
         self.COINDESK_API_KEY = os.getenv("COINDESK_API_KEY")
 
+        # Outbound HTTP: proxy, extra CA bundle, per-host TLS overrides
+        # ({"<host>": {"ca_bundle": ..., "client_cert": ..., "client_key": ...}}),
+        # timeout in seconds and retries for transient failures
+        self.OUTBOUND_PROXY = os.getenv("OUTBOUND_PROXY", "")
+        self.OUTBOUND_NO_PROXY = [h.strip() for h in os.getenv("OUTBOUND_NO_PROXY", "").split(",") if h.strip()]
+        self.OUTBOUND_CA_BUNDLE = os.getenv("OUTBOUND_CA_BUNDLE", "")
+        self.OUTBOUND_TLS_OVERRIDES: Dict[str, Dict[str, str]] = json.loads(os.getenv("OUTBOUND_TLS_OVERRIDES", "{}"))
+        self.OUTBOUND_TIMEOUT = float(os.getenv("OUTBOUND_TIMEOUT", "10"))
+        self.OUTBOUND_RETRIES = int(os.getenv("OUTBOUND_RETRIES", "2"))
+        self.OUTBOUND_RETRY_BACKOFF = float(os.getenv("OUTBOUND_RETRY_BACKOFF", "0.5"))
+
+        # Debug capture: full request/response pairs (secrets redacted) are stored
+        # for paths matching these globs, e.g. "/api/v1/quote*"
+        self.CAPTURE_ROUTES = [p.strip() for p in os.getenv("CAPTURE_ROUTES", "").split(",") if p.strip()]
+        self.CAPTURE_MAX_BODY_BYTES = int(os.getenv("CAPTURE_MAX_BODY_BYTES", "65536"))
+
+        # KV store: per-value size cap for /kv/{namespace}/{key}
+        self.KV_MAX_VALUE_BYTES = int(os.getenv("KV_MAX_VALUE_BYTES", "65536"))
+
+        # Cache: in-process LRU unless REDIS_URL points at a shared Redis
+        self.CACHE_MAX_ENTRIES = int(os.getenv("CACHE_MAX_ENTRIES", "10000"))
+        self.REDIS_URL = os.getenv("REDIS_URL", "")
+        self.REDIS_KEY_PREFIX = os.getenv("REDIS_KEY_PREFIX", "wavedex:")
+
+        # Market data: price providers tried in order until every symbol is priced
+        self.PRICE_PROVIDERS = [p.strip() for p in os.getenv("PRICE_PROVIDERS", "coingecko,binance").split(",") if p.strip()]
+        self.PRICE_CACHE_TTL = int(os.getenv("PRICE_CACHE_TTL", "30"))
+        self.BINANCE_BASE_URL = os.getenv("BINANCE_BASE_URL", "https://api.binance.com")
+        self.BINANCE_WS_URL = os.getenv("BINANCE_WS_URL", "wss://stream.binance.com:9443/ws")
+
+        # Notifications: per-event template overrides ({"<event>": {"title": ..., "body": ...}})
+        # and operator destinations for system events ({"<event>": [{"channel": ..., "to": ...}]})
+        self.NOTIFICATION_TEMPLATES: Dict[str, Dict[str, str]] = json.loads(os.getenv("NOTIFICATION_TEMPLATES", "{}"))
+        self.NOTIFICATION_ROUTES: Dict[str, List[Dict[str, str]]] = json.loads(os.getenv("NOTIFICATION_ROUTES", "{}"))
+        # Hosts (globs allowed) that generic webhook destinations may point at;
+        # empty disables the webhook channel
+        self.NOTIFICATION_WEBHOOK_HOSTS = [h.strip() for h in os.getenv("NOTIFICATION_WEBHOOK_HOSTS", "").split(",") if h.strip()]
+        self.SMTP_HOST = os.getenv("SMTP_HOST", "")
+        self.SMTP_PORT = int(os.getenv("SMTP_PORT", "587"))
+        self.SMTP_USERNAME = os.getenv("SMTP_USERNAME", "")
+        self.SMTP_PASSWORD = os.getenv("SMTP_PASSWORD", "")
+        self.SMTP_FROM = os.getenv("SMTP_FROM", "wavedex@localhost")
+        self.SMTP_STARTTLS = os.getenv("SMTP_STARTTLS", "true").lower() in ("true", "1", "t")
+
+        # Arbitrage scanner: compares providers and reports spreads net of fees
+        self.ARBITRAGE_ENABLED = os.getenv("ARBITRAGE_ENABLED", "false").lower() in ("true", "1", "t")
+        self.ARBITRAGE_SYMBOLS = [s.strip().upper() for s in os.getenv("ARBITRAGE_SYMBOLS", "BTC,ETH,SOL").split(",") if s.strip()]
+        self.ARBITRAGE_PROVIDERS = [p.strip() for p in os.getenv("ARBITRAGE_PROVIDERS", "coingecko,binance").split(",") if p.strip()]
+        self.ARBITRAGE_SCAN_INTERVAL = int(os.getenv("ARBITRAGE_SCAN_INTERVAL", "60"))
+        self.ARBITRAGE_FEE_PCT = float(os.getenv("ARBITRAGE_FEE_PCT", "0.1"))
+        self.ARBITRAGE_MIN_SPREAD_PCT = float(os.getenv("ARBITRAGE_MIN_SPREAD_PCT", "0.5"))
+        self.ARBITRAGE_ALERT_CHAT_IDS = [int(c) for c in os.getenv("ARBITRAGE_ALERT_CHAT_IDS", "").split(",") if c.strip()]
+
+        # DEX aggregators: 0x and 1inch need API keys and are skipped without one
+        self.ZEROX_API_KEY = os.getenv("ZEROX_API_KEY", "")
+        self.ONEINCH_API_KEY = os.getenv("ONEINCH_API_KEY", "")
+        self.ONEINCH_API_URL = os.getenv("ONEINCH_API_URL", "https://api.1inch.dev/swap/v6.0")
+        self.JUPITER_API_URL = os.getenv("JUPITER_API_URL", "https://quote-api.jup.ag/v6")
+        self.QUOTE_CACHE_TTL = int(os.getenv("QUOTE_CACHE_TTL", "10"))
+
+        # Chains: RPC endpoints per chain (a URL or a list tried in order)
+        self.ETH_RPC_URL = os.getenv("ETH_RPC_URL", "https://cloudflare-eth.com")
+        self.CHAIN_RPC_URLS: Dict[str, Any] = json.loads(os.getenv("CHAIN_RPC_URLS", "{}"))
+        self.CHAIN_HEALTH_CHECK_INTERVAL = int(os.getenv("CHAIN_HEALTH_CHECK_INTERVAL", "60"))
+        self.GAS_CACHE_TTL = int(os.getenv("GAS_CACHE_TTL", "12"))
+
+        # Portfolio tracking
+        self.PORTFOLIO_SNAPSHOT_INTERVAL = int(os.getenv("PORTFOLIO_SNAPSHOT_INTERVAL", "3600"))
+
+        # API Authentication
+        self.ADMIN_API_KEY = os.getenv("ADMIN_API_KEY", "")
+        self.AUTH_DISABLED = os.getenv("AUTH_DISABLED", "false").lower() in ("true", "1", "t")
+        self.JWT_ALGORITHM = os.getenv("JWT_ALGORITHM", "HS256")
+        self.JWT_SECRET = os.getenv("JWT_SECRET", self.APP_SECRET_KEY)
+        self.JWT_PRIVATE_KEY_FILE = os.getenv("JWT_PRIVATE_KEY_FILE", "")
+        self.JWT_PUBLIC_KEY_FILE = os.getenv("JWT_PUBLIC_KEY_FILE", "")
+        self.JWT_ISSUER = os.getenv("JWT_ISSUER", "wavedex-bot")
+        self.JWT_ACCESS_TOKEN_TTL = int(os.getenv("JWT_ACCESS_TOKEN_TTL", "900"))
+        self.JWT_REFRESH_TOKEN_TTL = int(os.getenv("JWT_REFRESH_TOKEN_TTL", str(30 * 24 * 3600)))
+
+        # Two-factor auth: when required, high-privilege operations are refused
+        # for callers that haven't enrolled a TOTP authenticator
+        self.TOTP_REQUIRED = os.getenv("TOTP_REQUIRED", "false").lower() in ("true", "1", "t")
+        self.TOTP_ISSUER = os.getenv("TOTP_ISSUER", "WaveDex-Bot")
+
+        # HMAC request signing: {"<client id>": {"secret": "...", "scopes": [...]}}
+        self.HMAC_CLIENTS: Dict[str, Dict[str, Any]] = json.loads(os.getenv("HMAC_CLIENTS", "{}"))
+        self.HMAC_CLOCK_SKEW = int(os.getenv("HMAC_CLOCK_SKEW", "300"))
+
+        # Rate limiting: "<requests per second>/<burst>"
+        self.RATE_LIMIT_ENABLED = os.getenv("RATE_LIMIT_ENABLED", "true").lower() in ("true", "1", "t")
+        self.RATE_LIMIT_READ = os.getenv("RATE_LIMIT_READ", "10/20")
+        self.RATE_LIMIT_WRITE = os.getenv("RATE_LIMIT_WRITE", "2/5")
+        # Per client IP on protected routes, checked before credentials are
+        self.RATE_LIMIT_PER_IP = os.getenv("RATE_LIMIT_PER_IP", "20/40")
+        # {"<scope>": "<rps>/<burst>"}; the first entry matching a caller's scopes replaces the defaults
+        self.RATE_LIMIT_SCOPE_OVERRIDES: Dict[str, str] = json.loads(os.getenv("RATE_LIMIT_SCOPE_OVERRIDES", "{}"))
+
+        # IP access control: comma-separated IPs/CIDRs. IP_ACCESS_FILE (JSON with
+        # "allow", "deny" and "trusted_proxies" lists) overrides these and is
+        # reloaded when it changes.
+        self.IP_ALLOWLIST = os.getenv("IP_ALLOWLIST", "")
+        self.IP_DENYLIST = os.getenv("IP_DENYLIST", "")
+        self.TRUSTED_PROXIES = os.getenv("TRUSTED_PROXIES", "")
+        self.IP_ACCESS_FILE = os.getenv("IP_ACCESS_FILE", "")
+
+        # Client certificates verified by a TLS-terminating proxy in
+        # TRUSTED_PROXIES: {"<CN or SAN>": [scopes...]}
+        self.CLIENT_CERT_HEADER = os.getenv("CLIENT_CERT_HEADER", "X-Forwarded-Client-Cert")
+        self.CLIENT_CERT_IDENTITIES: Dict[str, List[str]] = json.loads(os.getenv("CLIENT_CERT_IDENTITIES", "{}"))
+
+        # OpenID Connect login (disabled unless OIDC_ISSUER is set)
+        self.OIDC_ISSUER = os.getenv("OIDC_ISSUER", "").rstrip("/")
+        self.OIDC_CLIENT_ID = os.getenv("OIDC_CLIENT_ID", "")
+        self.OIDC_CLIENT_SECRET = os.getenv("OIDC_CLIENT_SECRET", "")
+        self.OIDC_REDIRECT_URI = os.getenv("OIDC_REDIRECT_URI", "")
+        self.OIDC_SCOPES = os.getenv("OIDC_SCOPES", "openid email profile")
+        self.OIDC_GROUPS_CLAIM = os.getenv("OIDC_GROUPS_CLAIM", "groups")
+        self.OIDC_GROUP_ROLES: Dict[str, str] = json.loads(os.getenv("OIDC_GROUP_ROLES", "{}"))
+
         # Validate required settings
         if not self.APP_SECRET_KEY:
             raise ValueError("APP_SECRET_KEY must be set in .env file")
@@ -40,6 +210,33 @@ class Environment:
         if not self.TELEGRAM_BOT_TOKEN:
             raise ValueError("TELEGRAM_BOT_TOKEN must be set in .env file")
 
+        if self.AUTH_DISABLED and self.APP_ENV == "production":
+            raise ValueError("AUTH_DISABLED cannot be used when APP_ENV is production")
+
+        if self.OIDC_ISSUER and not (self.OIDC_CLIENT_ID and self.OIDC_REDIRECT_URI):
+            raise ValueError("OIDC_CLIENT_ID and OIDC_REDIRECT_URI must be set when OIDC_ISSUER is set")
+
+        unknown_providers = set(self.PRICE_PROVIDERS + self.ARBITRAGE_PROVIDERS) - {"coingecko", "binance"}
+        if unknown_providers:
+            raise ValueError(f"Unknown PRICE_PROVIDERS: {', '.join(sorted(unknown_providers))}")
+
+        if self.LOG_FORMAT not in ("text", "json"):
+            raise ValueError("LOG_FORMAT must be text or json")
+
+        if self.CORS_ALLOW_CREDENTIALS and "*" in self.CORS_ALLOWED_ORIGINS:
+            raise ValueError("CORS_ALLOW_CREDENTIALS cannot be combined with a wildcard origin")
+
+        if bool(self.SSL_CERTFILE) != bool(self.SSL_KEYFILE):
+            raise ValueError("SSL_CERTFILE and SSL_KEYFILE must be set together")
+
+        if self.TLS_CLIENT_CA and not self.SSL_CERTFILE:
+            raise ValueError("TLS_CLIENT_CA requires SSL_CERTFILE and SSL_KEYFILE")
+        if self.TLS_CLIENT_CERT_REQUIRED and not self.TLS_CLIENT_CA:
+            raise ValueError("TLS_CLIENT_CERT_REQUIRED needs TLS_CLIENT_CA to verify certificates against")
+
+        if self.JWT_ALGORITHM not in ("HS256", "RS256"):
+            raise ValueError("JWT_ALGORITHM must be HS256 or RS256")
+
     def __getattr__(self, name: str) -> Any:
         """Allow accessing attributes with dot notation"""
         try:
@@ -47,5 +244,15 @@ class Environment:
         except KeyError:
             raise AttributeError(f"'{self.__class__.__name__}' object has no attribute '{name}'")
 
+    def reload(self):
+        """Re-read .env and the environment in place.
+
+        The new settings are validated before any of them are applied, so an
+        invalid change leaves the running configuration untouched.
+        """
+        load_dotenv(override=True)
+        fresh = Environment()
+        self.__dict__.update(fresh.__dict__)
+
 # Create a single instance of the environment
 env = Environment()
@@ -1,10 +1,22 @@
-from fastapi import FastAPI, Request
+from fastapi import Depends, FastAPI, Request
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.middleware.httpsredirect import HTTPSRedirectMiddleware
 from loguru import logger
+import ssl
 import uvicorn
 
 from app.core.logging import setup_logging
+from app.core.errors import BodySizeLimitMiddleware, recovery_middleware, register_error_handlers
+from app.core.capture import CaptureMiddleware
+from app.core.compression import StreamingAwareGZipMiddleware
+from app.core.ip_filter import ip_filter_middleware
+from app.core.maintenance import MaintenanceMiddleware
+from app.core.request_logging import request_logging_middleware
+from app.core.metrics import metrics_endpoint, metrics_middleware
+from app.core.tracing import setup_tracing, shutdown_tracing
+from app.core.versioning import API_PREFIX, LEGACY_API_PREFIX, legacy_api_notice
 from app.api.routes import router as api_router
+from app.api.routes.health import probe_router
 from app.core.telegram import bot_instance
 from env import env
 
@@ -15,49 +27,169 @@ app = FastAPI(
     version="1.0.0",
 )
 
-# Setup CORS
-app.add_middleware(
-    CORSMiddleware,
-    allow_origins=["*"],
-    allow_credentials=True,
-    allow_methods=["*"],
-    allow_headers=["*"],
-)
+# Uniform error envelope for every endpoint
+register_error_handlers(app)
+
+# Innermost: log crashes with the request ID and answer with a 500 envelope
+app.middleware("http")(recovery_middleware)
+app.add_middleware(BodySizeLimitMiddleware, max_bytes=env.MAX_REQUEST_BODY_BYTES)
+
+# Record requests on CAPTURE_ROUTES for debugging and replay; inside GZip so
+# response bodies are stored uncompressed
+app.add_middleware(CaptureMiddleware)
+
+# Compress larger responses for clients that accept gzip
+if env.GZIP_ENABLED:
+    app.add_middleware(StreamingAwareGZipMiddleware, minimum_size=env.GZIP_MIN_SIZE, compresslevel=env.GZIP_LEVEL)
+
+# Refuse new writes during maintenance and count requests still running;
+# inside CORS so browsers can read the 503
+app.add_middleware(MaintenanceMiddleware)
+
+# Setup CORS for browser clients on the configured origins only
+if env.CORS_ALLOWED_ORIGINS:
+    app.add_middleware(
+        CORSMiddleware,
+        allow_origins=env.CORS_ALLOWED_ORIGINS,
+        allow_credentials=env.CORS_ALLOW_CREDENTIALS,
+        allow_methods=env.CORS_ALLOWED_METHODS,
+        allow_headers=env.CORS_ALLOWED_HEADERS,
+        expose_headers=["X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"],
+        max_age=env.CORS_MAX_AGE,
+    )
+
+# Enforce IP allow/deny lists before anything else handles the request
+app.middleware("http")(ip_filter_middleware)
+
+# Count and time requests
+if env.METRICS_ENABLED:
+    app.middleware("http")(metrics_middleware)
+
+# Log every request, including ones rejected by the IP filter
+app.middleware("http")(request_logging_middleware)
+
+# Send plain HTTP clients to https
+if env.HTTPS_REDIRECT:
+    app.add_middleware(HTTPSRedirectMiddleware)
 
 # Setup logging
 setup_logging()
 
+# Setup tracing
+setup_tracing(app)
+
 # Include API routes
-app.include_router(api_router, prefix="/api")
+app.include_router(api_router, prefix=API_PREFIX)
+# Unversioned routes stay reachable for existing clients, marked deprecated
+app.include_router(
+    api_router,
+    prefix=LEGACY_API_PREFIX,
+    deprecated=True,
+    include_in_schema=False,
+    dependencies=[Depends(legacy_api_notice)],
+)
+app.include_router(probe_router)
 
 @app.on_event("startup")
 async def startup_event():
     logger.info("Starting up Crypto News Bot...")
+    if env.AUTH_DISABLED:
+        logger.warning("AUTH_DISABLED is set - API authentication is OFF. Do not use this outside development.")
     # Initialize database connection
     from app.core.db import db
     await db.connect()
     logger.info("Database connection established")
+
+    # Drop revocation entries for tokens that have expired since the last run
+    from app.services.token_revocation_service import token_revocation_service
+    await token_revocation_service.purge_expired()
+    # Reads already hide expired KV entries; this just reclaims the rows
+    from app.services.kv_service import kv_service
+    await kv_service.purge_expired()
+
+    # Serve metrics on their own port, away from the public API
+    if env.METRICS_ENABLED and env.METRICS_PORT:
+        from prometheus_client import start_http_server
+        start_http_server(env.METRICS_PORT, addr=env.METRICS_HOST)
+        logger.info(f"Metrics listening on {env.METRICS_HOST}:{env.METRICS_PORT}")
     
     # Initialize bot
     await bot_instance.initialize()
 
+    from app.services.portfolio_service import portfolio_service
+    await portfolio_service.start_snapshots()
+    from app.services.arbitrage_service import arbitrage_service
+    await arbitrage_service.start()
+    from app.services.chain_registry import chain_registry
+    await chain_registry.start()
+
+    from app.core.config_reload import install_sighup_handler
+    install_sighup_handler()
+
 @app.on_event("shutdown")
 async def shutdown_event():
     logger.info("Shutting down Crypto News Bot...")
     await bot_instance.shutdown()
+    # Stop background loops first; they still use the clients closed below
+    from app.services.arbitrage_service import arbitrage_service
+    await arbitrage_service.stop()
+    from app.services.portfolio_service import portfolio_service
+    await portfolio_service.close()
+    from app.services.market_stream_service import market_stream_service
+    await market_stream_service.close()
+    from app.services.chain_registry import chain_registry
+    await chain_registry.close()
+    from app.services.oidc_service import oidc_service
+    await oidc_service.close()
+    from app.services.market_data_service import market_data_service
+    await market_data_service.close()
+    from app.services.candle_service import candle_service
+    await candle_service.close()
+    from app.services.dex_quote_service import dex_quote_service
+    await dex_quote_service.close()
+    from app.services.notification_service import notification_service
+    await notification_service.close()
+    from app.services.cache_service import CacheService
+    await CacheService().close()
     # Close database connection
     from app.core.db import db
     await db.disconnect()
     logger.info("Database connection closed")
+    shutdown_tracing()
+    # Flush any queued log messages before the process exits
+    await logger.complete()
 
 @app.get("/health")
 async def health_check():
     return {"status": "healthy"}
 
+if env.METRICS_ENABLED and not env.METRICS_PORT:
+    app.add_api_route("/metrics", metrics_endpoint, methods=["GET"], include_in_schema=False)
+
+def tls_options() -> dict:
+    """uvicorn TLS settings, empty when serving plain HTTP"""
+    if not env.SSL_CERTFILE:
+        return {}
+    options = {
+        "ssl_certfile": env.SSL_CERTFILE,
+        "ssl_keyfile": env.SSL_KEYFILE,
+        "ssl_keyfile_password": env.SSL_KEYFILE_PASSWORD,
+        "ssl_ciphers": env.SSL_CIPHERS,
+    }
+    if env.TLS_CLIENT_CA:
+        options["ssl_ca_certs"] = env.TLS_CLIENT_CA
+        options["ssl_cert_reqs"] = ssl.CERT_REQUIRED if env.TLS_CLIENT_CERT_REQUIRED else ssl.CERT_OPTIONAL
+    return options
+
 if __name__ == "__main__":
     uvicorn.run(
         "main:app",  # Updated to point to root main.py
         host=env.HOST,
         port=env.PORT,
-        reload=True
+        reload=True,
+        timeout_graceful_shutdown=env.SHUTDOWN_GRACE_PERIOD,
+        h11_max_incomplete_event_size=env.MAX_HEADER_BYTES,
+        timeout_keep_alive=env.KEEP_ALIVE_TIMEOUT,
+        limit_concurrency=env.MAX_CONCURRENT_CONNECTIONS,
+        **tls_options()
     ) 
\ No newline at end of file
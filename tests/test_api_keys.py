@@ -0,0 +1,29 @@
+from app.services.api_key_service import api_key_service
+
+async def test_only_the_key_hash_is_stored(prisma):
+    raw_key, key = await api_key_service.create_key("ci", ["prices:read"])
+
+    stored = prisma.apikey.records[0]
+    assert stored.keyHash == api_key_service.hash_key(raw_key)
+    assert raw_key not in vars(stored).values()
+    assert raw_key.startswith(api_key_service.KEY_PREFIX + key.prefix)
+
+async def test_verify_key_needs_the_exact_key():
+    raw_key, key = await api_key_service.create_key("ci", ["prices:read"])
+
+    assert (await api_key_service.verify_key(raw_key)).id == key.id
+    assert await api_key_service.verify_key(raw_key[:-1]) is None
+    assert await api_key_service.verify_key(raw_key + "x") is None
+    assert await api_key_service.verify_key("") is None
+
+async def test_hash_without_prefix_is_not_a_key():
+    raw_key, _ = await api_key_service.create_key("ci", ["prices:read"])
+
+    assert await api_key_service.verify_key(api_key_service.hash_key(raw_key)) is None
+
+async def test_revoked_key_is_rejected():
+    raw_key, key = await api_key_service.create_key("ci", ["prices:read"])
+    await api_key_service.revoke_key(key.id)
+
+    assert await api_key_service.verify_key(raw_key) is None
+    assert not await api_key_service.is_active(key.id)
\ No newline at end of file
@@ -0,0 +1,60 @@
+import pytest
+from fastapi import HTTPException
+from fastapi.security import HTTPAuthorizationCredentials, SecurityScopes
+
+from env import env
+from app.core.security import get_current_principal, principal_from_api_key, token_signer
+from app.services.api_key_service import api_key_service
+
+def bearer(token: str) -> HTTPAuthorizationCredentials:
+    return HTTPAuthorizationCredentials(scheme="Bearer", credentials=token)
+
+async def test_request_without_credentials_is_rejected(make_request):
+    with pytest.raises(HTTPException) as exc:
+        await get_current_principal(make_request(), SecurityScopes(), None, None)
+    assert exc.value.status_code == 401
+    assert exc.value.headers["WWW-Authenticate"] == "Bearer"
+
+async def test_invalid_bearer_token_is_rejected(make_request):
+    with pytest.raises(HTTPException) as exc:
+        await get_current_principal(make_request(), SecurityScopes(), bearer("not-a-jwt"), None)
+    assert exc.value.status_code == 401
+
+async def test_unknown_api_key_is_rejected(make_request):
+    with pytest.raises(HTTPException) as exc:
+        await get_current_principal(make_request(), SecurityScopes(), None, "wdx_unknown")
+    assert exc.value.status_code == 401
+
+async def test_bearer_token_authenticates(make_request):
+    token = token_signer.issue("key:1", ["prices:read"])["access_token"]
+    request = make_request()
+
+    principal = await get_current_principal(request, SecurityScopes(), bearer(token), None)
+
+    assert principal.subject == "key:1"
+    assert request.state.principal is principal
+
+async def test_api_key_authenticates(make_request):
+    raw_key, key = await api_key_service.create_key("ci", ["prices:read"])
+
+    principal = await get_current_principal(make_request(), SecurityScopes(), None, raw_key)
+
+    assert principal.subject == f"key:{key.id}"
+
+async def test_route_scopes_are_checked(make_request):
+    raw_key, _ = await api_key_service.create_key("ci", ["prices:read"])
+
+    with pytest.raises(HTTPException) as exc:
+        await get_current_principal(make_request(), SecurityScopes(["alerts:write"]), None, raw_key)
+    assert exc.value.status_code == 403
+
+async def test_admin_api_key_authenticates(make_request, monkeypatch):
+    monkeypatch.setattr(env, "ADMIN_API_KEY", "bootstrap-admin-key")
+
+    principal = await get_current_principal(make_request(), SecurityScopes(["keys:manage"]), None, "bootstrap-admin-key")
+
+    assert principal.subject == "admin"
+
+async def test_unset_admin_api_key_matches_nothing():
+    # ADMIN_API_KEY defaults to empty; an empty key must not compare equal to it
+    assert await principal_from_api_key("") is None
\ No newline at end of file
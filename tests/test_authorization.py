@@ -0,0 +1,85 @@
+import pytest
+from fastapi import HTTPException
+
+from app.api.routes.keys import create_key
+from app.core.authorization import authorize
+from app.core.security import Principal
+from app.models.schemas import ApiKeyCreate
+
+def route_request(make_request, method: str, route_path: str):
+    return make_request(method, route_path, route_path=route_path)
+
+async def test_unlisted_route_is_denied(make_request):
+    principal = Principal(subject="key:1", scopes=["*:read", "*:write"])
+
+    with pytest.raises(HTTPException) as exc:
+        await authorize(route_request(make_request, "GET", "/api/v1/not-in-permissions"), principal)
+    assert exc.value.status_code == 403
+
+async def test_unlisted_method_is_denied(make_request):
+    principal = Principal(subject="key:1", scopes=["prices:read", "prices:write"])
+
+    with pytest.raises(HTTPException) as exc:
+        await authorize(route_request(make_request, "DELETE", "/api/v1/prices"), principal)
+    assert exc.value.status_code == 403
+
+async def test_admin_may_use_unlisted_routes(make_request):
+    principal = Principal(subject="admin", scopes=["admin"])
+
+    assert await authorize(route_request(make_request, "GET", "/api/v1/not-in-permissions"), principal) is principal
+
+async def test_listed_route_requires_its_scopes(make_request):
+    reader = Principal(subject="key:1", scopes=["alerts:read"])
+    writer = Principal(subject="key:2", scopes=["alerts:write"])
+    request = route_request(make_request, "POST", "/api/v1/alerts")
+
+    with pytest.raises(HTTPException) as exc:
+        await authorize(request, reader)
+    assert exc.value.status_code == 403
+    assert await authorize(request, writer) is writer
+
+async def test_permissions_apply_to_the_legacy_prefix(make_request):
+    principal = Principal(subject="key:1", scopes=["prices:read"])
+
+    with pytest.raises(HTTPException):
+        await authorize(route_request(make_request, "POST", "/api/alerts"), principal)
+
+async def test_route_template_is_checked_not_the_raw_path(make_request):
+    principal = Principal(subject="key:1", scopes=["alerts:read"])
+    request = make_request("DELETE", "/api/v1/alerts/123", route_path="/api/v1/alerts/{alert_id}")
+
+    with pytest.raises(HTTPException) as exc:
+        await authorize(request, principal)
+    assert exc.value.status_code == 403
+
+@pytest.mark.parametrize("granted, required, allowed", [
+    (["alerts:*"], ["alerts:write"], True),
+    (["alerts:*"], ["prices:read"], False),
+    (["*:read"], ["portfolio:read"], True),
+    (["*:read"], ["portfolio:write"], False),
+    (["role:read-only"], ["prices:read"], True),
+    (["role:read-only"], ["alerts:write"], False),
+    (["role:operator"], ["keys:manage"], False),
+    (["role:operator"], ["admin"], False),
+    (["role:admin"], ["keys:manage"], True),
+    (["role:unknown"], ["prices:read"], False),
+])
+def test_scope_matching(granted, required, allowed):
+    assert Principal(subject="key:1", scopes=granted).has_scopes(required) is allowed
+
+async def test_key_creation_cannot_escalate(make_request):
+    request = make_request("POST", "/api/v1/keys")
+    request.state.principal = Principal(subject="key:1", scopes=["keys:manage", "prices:read"])
+
+    for scopes in (["admin"], ["role:operator"], ["alerts:write"], ["*:read"]):
+        with pytest.raises(HTTPException) as exc:
+            await create_key(request, ApiKeyCreate(label="escalate", scopes=scopes))
+        assert exc.value.status_code == 403
+
+async def test_key_creation_within_own_scopes(make_request):
+    request = make_request("POST", "/api/v1/keys")
+    request.state.principal = Principal(subject="key:1", scopes=["keys:manage", "prices:read"])
+
+    created = await create_key(request, ApiKeyCreate(label="reader", scopes=["prices:read"]))
+
+    assert created.scopes == ["prices:read"]
\ No newline at end of file
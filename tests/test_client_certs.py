@@ -0,0 +1,93 @@
+from datetime import datetime, timedelta, timezone
+import pytest
+from cryptography import x509
+from cryptography.hazmat.primitives import hashes, serialization
+from cryptography.hazmat.primitives.asymmetric import ec
+from cryptography.x509.oid import NameOID
+
+from env import env
+from app.core.client_certs import fields_from_tls_extension, identity_from_client_cert
+from app.core.ip_filter import IPAccessPolicy, ip_filter
+
+PROXY = "10.0.0.1"
+XFCC = 'Hash=abc123;Subject="CN=ci.example.com,O=Example";URI=spiffe://example.com/billing'
+
+@pytest.fixture(autouse=True)
+def identities(monkeypatch):
+    monkeypatch.setattr(env, "CLIENT_CERT_IDENTITIES", {
+        "ci.example.com": ["prices:read"],
+        "spiffe://example.com/billing": ["portfolio:read"],
+    })
+    monkeypatch.setattr(ip_filter, "policy", IPAccessPolicy(trusted_proxies=[PROXY]))
+
+def pem_certificate(common_name: str, uris=()) -> str:
+    key = ec.generate_private_key(ec.SECP256R1())
+    name = x509.Name([x509.NameAttribute(NameOID.COMMON_NAME, common_name)])
+    now = datetime.now(timezone.utc)
+    builder = (
+        x509.CertificateBuilder()
+        .subject_name(name)
+        .issuer_name(name)
+        .public_key(key.public_key())
+        .serial_number(x509.random_serial_number())
+        .not_valid_before(now)
+        .not_valid_after(now + timedelta(days=1))
+    )
+    if uris:
+        builder = builder.add_extension(
+            x509.SubjectAlternativeName([x509.UniformResourceIdentifier(uri) for uri in uris]), critical=False
+        )
+    return builder.sign(key, hashes.SHA256()).public_bytes(serialization.Encoding.PEM).decode()
+
+def test_header_from_untrusted_peer_is_ignored(make_request):
+    request = make_request(client="203.0.113.7", headers={env.CLIENT_CERT_HEADER: XFCC})
+
+    assert identity_from_client_cert(request) is None
+
+def test_header_from_trusted_proxy_maps_the_common_name(make_request):
+    request = make_request(client=PROXY, headers={env.CLIENT_CERT_HEADER: XFCC})
+
+    assert identity_from_client_cert(request) == ("ci.example.com", ["prices:read"])
+
+def test_san_is_used_when_the_common_name_is_unmapped(make_request):
+    header = 'Subject="CN=unmapped";URI=spiffe://example.com/billing'
+    request = make_request(client=PROXY, headers={env.CLIENT_CERT_HEADER: header})
+
+    assert identity_from_client_cert(request) == ("spiffe://example.com/billing", ["portfolio:read"])
+
+def test_unmapped_certificate_is_rejected(make_request):
+    request = make_request(client=PROXY, headers={env.CLIENT_CERT_HEADER: 'Subject="CN=unmapped"'})
+
+    assert identity_from_client_cert(request) is None
+
+def test_only_the_element_added_by_the_proxy_counts(make_request):
+    # A client-supplied element, forwarded ahead of the proxy's own
+    header = f'{XFCC},Subject="CN=unmapped"'
+    request = make_request(client=PROXY, headers={env.CLIENT_CERT_HEADER: header})
+
+    assert identity_from_client_cert(request) is None
+
+def test_verified_listener_certificate_is_used(make_request):
+    tls = {"client_cert_chain": [pem_certificate("unmapped", uris=["spiffe://example.com/billing"])]}
+    request = make_request(client="203.0.113.7", extensions={"tls": tls})
+
+    assert identity_from_client_cert(request) == ("spiffe://example.com/billing", ["portfolio:read"])
+
+def test_listener_certificate_takes_precedence_over_the_header(make_request):
+    tls = {"client_cert_chain": [pem_certificate("unmapped")]}
+    request = make_request(client=PROXY, headers={env.CLIENT_CERT_HEADER: XFCC}, extensions={"tls": tls})
+
+    assert identity_from_client_cert(request) is None
+
+def test_listener_certificate_with_an_error_is_rejected(make_request):
+    tls = {"client_cert_name": "CN=ci.example.com", "client_cert_error": "certificate has expired"}
+    request = make_request(client="203.0.113.7", extensions={"tls": tls})
+
+    assert identity_from_client_cert(request) is None
+
+def test_certificate_fields_match_the_header_format():
+    fields = fields_from_tls_extension({"client_cert_chain": [pem_certificate("ci.example.com", uris=["spiffe://example.com/ci"])]})
+
+    assert fields["subject"] == ["CN=ci.example.com"]
+    assert fields["uri"] == ["spiffe://example.com/ci"]
+    assert fields["dns"] == []
\ No newline at end of file
@@ -0,0 +1,54 @@
+import pytest
+from fastapi.responses import PlainTextResponse
+
+from app.core.ip_filter import IPAccessPolicy, ip_filter, ip_filter_middleware
+
+PROXIES = IPAccessPolicy(trusted_proxies=["10.0.0.0/8"])
+
+def test_forwarded_for_from_untrusted_peer_is_ignored():
+    assert PROXIES.resolve_client_ip("203.0.113.7", "198.51.100.1") == "203.0.113.7"
+
+def test_forwarded_for_from_trusted_proxy_is_used():
+    assert PROXIES.resolve_client_ip("10.0.0.5", "198.51.100.1") == "198.51.100.1"
+
+def test_prepended_hops_cannot_spoof_the_client():
+    # The client sent "X-Forwarded-For: 1.2.3.4" and the proxy appended the real address
+    assert PROXIES.resolve_client_ip("10.0.0.5", "1.2.3.4, 198.51.100.1") == "198.51.100.1"
+
+def test_chained_trusted_proxies_are_skipped():
+    assert PROXIES.resolve_client_ip("10.0.0.5", "198.51.100.1, 10.0.0.9") == "198.51.100.1"
+
+def test_malformed_hop_stops_the_walk():
+    assert PROXIES.resolve_client_ip("10.0.0.5", "not-an-ip, 10.0.0.9") == "10.0.0.9"
+
+def test_no_trusted_proxies_means_no_forwarding():
+    assert IPAccessPolicy().resolve_client_ip("10.0.0.5", "198.51.100.1") == "10.0.0.5"
+
+@pytest.mark.parametrize("address, allowed", [
+    ("203.0.113.8", True),
+    ("203.0.113.7", False),
+    ("198.51.100.1", False),
+    ("testclient", False),
+])
+def test_deny_wins_and_allowlist_is_enforced(address, allowed):
+    policy = IPAccessPolicy(allow=["203.0.113.0/24"], deny=["203.0.113.7"])
+
+    assert policy.is_allowed(address) is allowed
+
+def test_empty_policy_allows_everyone():
+    assert IPAccessPolicy().is_allowed("198.51.100.1")
+    assert IPAccessPolicy().is_allowed("testclient")
+
+async def test_middleware_blocks_spoofed_forwarded_for(make_request, monkeypatch):
+    monkeypatch.setattr(ip_filter, "policy", IPAccessPolicy(allow=["198.51.100.0/24"], trusted_proxies=["10.0.0.1"]))
+
+    async def call_next(request):
+        return PlainTextResponse("ok")
+
+    spoofed = make_request(client="203.0.113.7", headers={"X-Forwarded-For": "198.51.100.1"})
+    assert (await ip_filter_middleware(spoofed, call_next)).status_code == 403
+    assert spoofed.state.client_ip == "203.0.113.7"
+
+    proxied = make_request(client="10.0.0.1", headers={"X-Forwarded-For": "198.51.100.1"})
+    assert (await ip_filter_middleware(proxied, call_next)).status_code == 200
+    assert proxied.state.client_ip == "198.51.100.1"
\ No newline at end of file
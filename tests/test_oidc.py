@@ -0,0 +1,150 @@
+from datetime import datetime, timedelta, timezone
+from urllib.parse import parse_qs, urlparse
+import base64
+import pytest
+from fastapi import HTTPException
+from jose import jwt
+
+from env import env
+from app.api.routes.auth import oidc_callback
+from app.services.oidc_service import oidc_service, OIDCError
+
+ISSUER = "https://idp.example.com"
+CLIENT_ID = "wavedex"
+IDP_SECRET = "idp-signing-secret"
+
+class FakeResponse:
+    def __init__(self, data):
+        self._data = data
+
+    def raise_for_status(self):
+        pass
+
+    def json(self):
+        return self._data
+
+class FakeIdP:
+    """Token endpoint that answers every code exchange with the configured ID token"""
+
+    def __init__(self):
+        self.id_token = None
+
+    async def post(self, url, data):
+        return FakeResponse({"id_token": self.id_token})
+
+@pytest.fixture
+def idp(monkeypatch) -> FakeIdP:
+    monkeypatch.setattr(env, "OIDC_ISSUER", ISSUER)
+    monkeypatch.setattr(env, "OIDC_CLIENT_ID", CLIENT_ID)
+    monkeypatch.setattr(env, "OIDC_REDIRECT_URI", "https://bot.example.com/api/v1/auth/oidc/callback")
+    monkeypatch.setattr(env, "OIDC_GROUP_ROLES", {"ops": "operator", "viewers": "read-only"})
+
+    async def metadata():
+        return {
+            "issuer": ISSUER,
+            "authorization_endpoint": f"{ISSUER}/authorize",
+            "token_endpoint": f"{ISSUER}/token",
+            "id_token_signing_alg_values_supported": ["HS256"],
+        }
+
+    async def jwks():
+        k = base64.urlsafe_b64encode(IDP_SECRET.encode()).rstrip(b"=").decode()
+        return {"keys": [{"kty": "oct", "k": k, "alg": "HS256"}]}
+
+    fake = FakeIdP()
+    monkeypatch.setattr(oidc_service, "_metadata", metadata)
+    monkeypatch.setattr(oidc_service, "_jwks", jwks)
+    monkeypatch.setattr(oidc_service, "client", fake)
+    return fake
+
+async def start_login():
+    """Begin a login and return the (state, nonce) the IdP would echo back"""
+    params = parse_qs(urlparse(await oidc_service.authorization_url()).query)
+    return params["state"][0], params["nonce"][0]
+
+def id_token(nonce: str, **overrides) -> str:
+    now = datetime.now(timezone.utc)
+    claims = {
+        "iss": ISSUER,
+        "aud": CLIENT_ID,
+        "sub": "user-1",
+        "nonce": nonce,
+        "groups": ["ops"],
+        "iat": int(now.timestamp()),
+        "exp": int((now + timedelta(minutes=5)).timestamp()),
+    }
+    claims.update(overrides)
+    return jwt.encode(claims, IDP_SECRET, algorithm="HS256")
+
+async def test_login_returns_the_verified_claims(idp):
+    state, nonce = await start_login()
+    idp.id_token = id_token(nonce)
+
+    claims = await oidc_service.complete_login("code", state)
+
+    assert claims["sub"] == "user-1"
+
+async def test_unknown_state_is_rejected(idp):
+    with pytest.raises(OIDCError):
+        await oidc_service.complete_login("code", "never-issued")
+
+async def test_state_is_single_use(idp):
+    state, nonce = await start_login()
+    idp.id_token = id_token(nonce)
+    await oidc_service.complete_login("code", state)
+
+    with pytest.raises(OIDCError, match="login state"):
+        await oidc_service.complete_login("code", state)
+
+async def test_nonce_mismatch_is_rejected(idp):
+    state, _ = await start_login()
+    idp.id_token = id_token("replayed-nonce")
+
+    with pytest.raises(OIDCError, match="nonce"):
+        await oidc_service.complete_login("code", state)
+
+@pytest.mark.parametrize("overrides", [
+    {"aud": "some-other-client"},
+    {"iss": "https://evil.example.com"},
+    {"exp": 0},
+])
+async def test_id_token_claims_are_verified(idp, overrides):
+    state, nonce = await start_login()
+    idp.id_token = id_token(nonce, **overrides)
+
+    with pytest.raises(OIDCError, match="Invalid ID token"):
+        await oidc_service.complete_login("code", state)
+
+async def test_id_token_signed_by_someone_else_is_rejected(idp):
+    state, nonce = await start_login()
+    idp.id_token = jwt.encode({"iss": ISSUER, "aud": CLIENT_ID, "sub": "user-1", "nonce": nonce}, "forged", algorithm="HS256")
+
+    with pytest.raises(OIDCError, match="Invalid ID token"):
+        await oidc_service.complete_login("code", state)
+
+@pytest.mark.parametrize("groups, roles", [
+    (["ops", "unmapped"], ["role:operator"]),
+    (["ops", "viewers"], ["role:operator", "role:read-only"]),
+    ("viewers", ["role:read-only"]),
+    (["unmapped"], []),
+    (None, []),
+])
+def test_groups_map_to_roles(idp, groups, roles):
+    assert oidc_service.roles_for({"sub": "user-1", "groups": groups}) == roles
+
+async def test_callback_issues_no_refresh_token(idp):
+    state, nonce = await start_login()
+    idp.id_token = id_token(nonce)
+
+    response = await oidc_callback("code", state)
+
+    assert response.scope == "role:operator"
+    assert response.refresh_token is None
+
+async def test_callback_refuses_unmapped_users(idp):
+    state, nonce = await start_login()
+    idp.id_token = id_token(nonce, groups=["unmapped"])
+
+    with pytest.raises(HTTPException) as exc:
+        await oidc_callback("code", state)
+    assert exc.value.status_code == 403
\ No newline at end of file
@@ -0,0 +1,127 @@
+from types import SimpleNamespace
+import pytest
+from fastapi import HTTPException, Response
+
+from env import env
+from app.core import rate_limit
+from app.core.rate_limit import Limit, RateLimiter, ip_rate_limit
+
+class Clock:
+    def __init__(self):
+        self.now = 1000.0
+
+    def monotonic(self) -> float:
+        return self.now
+
+@pytest.fixture
+def clock(monkeypatch) -> Clock:
+    clock = Clock()
+    monkeypatch.setattr(rate_limit, "time", SimpleNamespace(monotonic=clock.monotonic))
+    return clock
+
+@pytest.fixture
+def limiter(clock) -> RateLimiter:
+    return RateLimiter()
+
+LIMIT = Limit(rate=1, burst=3)
+
+def test_burst_then_reject(limiter):
+    results = [limiter.hit("key:1", "read", LIMIT)[0] for _ in range(4)]
+
+    assert results == [True, True, True, False]
+    allowed, remaining, retry_after = limiter.hit("key:1", "read", LIMIT)
+    assert not allowed and retry_after == pytest.approx(1.0)
+
+def test_bucket_refills_over_time(limiter, clock):
+    for _ in range(3):
+        limiter.hit("key:1", "read", LIMIT)
+    assert not limiter.hit("key:1", "read", LIMIT)[0]
+
+    clock.now += 1
+    assert limiter.hit("key:1", "read", LIMIT)[0]
+    assert not limiter.hit("key:1", "read", LIMIT)[0]
+
+def test_refill_is_capped_at_burst(limiter, clock):
+    limiter.hit("key:1", "read", LIMIT)
+    clock.now += 60
+
+    results = [limiter.hit("key:1", "read", LIMIT)[0] for _ in range(4)]
+    assert results == [True, True, True, False]
+
+def test_callers_and_request_classes_have_separate_buckets(limiter):
+    for _ in range(3):
+        limiter.hit("key:1", "read", LIMIT)
+
+    assert limiter.hit("key:2", "read", LIMIT)[0]
+    assert limiter.hit("key:1", "write", LIMIT)[0]
+
+def test_bucket_count_is_capped(limiter):
+    limiter.MAX_BUCKETS = 3
+    for caller in range(10):
+        limiter.hit(f"ip:10.0.0.{caller}", "ip", LIMIT)
+
+    assert len(limiter._buckets) == 3
+    assert list(limiter._buckets) == [(f"ip:10.0.0.{caller}", "ip") for caller in (7, 8, 9)]
+
+def test_least_recently_seen_bucket_is_evicted_first(limiter):
+    limiter.MAX_BUCKETS = 3
+    for caller in ("a", "b", "c"):
+        limiter.hit(caller, "read", LIMIT)
+    limiter.hit("a", "read", LIMIT)
+
+    limiter.hit("d", "read", LIMIT)
+
+    assert set(key[0] for key in limiter._buckets) == {"a", "c", "d"}
+
+def test_idle_buckets_are_forgotten(limiter, clock):
+    limiter.hit("idle", "read", LIMIT)
+    clock.now += limiter.IDLE_BUCKET_TTL + 1
+
+    limiter.hit("active", "read", LIMIT)
+
+    assert list(limiter._buckets) == [("active", "read")]
+
+def test_scope_overrides_take_precedence(limiter):
+    partner, trial = Limit(rate=100, burst=200), Limit(rate=0.1, burst=1)
+    limiter.scope_overrides = {"partner": partner, "trial": trial}
+
+    assert limiter.limit_for("read", ["prices:read", "partner"]) is partner
+    # Overrides can tighten the default as well as loosen it
+    assert limiter.limit_for("write", ["trial"]) is trial
+    assert limiter.limit_for("read", ["prices:read"]) is limiter.limits["read"]
+    assert limiter.limit_for("read") is limiter.limits["read"]
+
+@pytest.mark.parametrize("value", ["0/5", "-1/5", "5/0", "fast"])
+def test_invalid_limits_are_refused(value):
+    with pytest.raises(ValueError):
+        Limit.parse(value)
+
+async def test_ip_limit_answers_429(make_request, monkeypatch, limiter):
+    monkeypatch.setattr(env, "RATE_LIMIT_ENABLED", True)
+    monkeypatch.setattr(rate_limit, "rate_limiter", limiter)
+    limiter.limits["ip"] = Limit(rate=1, burst=2)
+
+    for _ in range(2):
+        response = Response()
+        await ip_rate_limit(make_request(client="198.51.100.7"), response)
+    assert response.headers["RateLimit-Remaining"] == "0"
+
+    with pytest.raises(HTTPException) as exc:
+        await ip_rate_limit(make_request(client="198.51.100.7"), Response())
+    assert exc.value.status_code == 429
+    assert exc.value.headers["Retry-After"] == "1"
+
+    # A different address is unaffected
+    await ip_rate_limit(make_request(client="198.51.100.8"), Response())
+
+async def test_ip_limit_uses_the_resolved_client_ip(make_request, monkeypatch, limiter):
+    monkeypatch.setattr(env, "RATE_LIMIT_ENABLED", True)
+    monkeypatch.setattr(rate_limit, "rate_limiter", limiter)
+    limiter.limits["ip"] = Limit(rate=1, burst=1)
+
+    request = make_request(client="10.0.0.1")
+    request.state.client_ip = "198.51.100.7"
+    await ip_rate_limit(request, Response())
+
+    with pytest.raises(HTTPException):
+        await ip_rate_limit(make_request(client="198.51.100.7"), Response())
\ No newline at end of file
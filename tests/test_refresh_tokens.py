@@ -0,0 +1,92 @@
+from datetime import datetime, timedelta, timezone
+import pytest
+from fastapi import HTTPException
+
+from env import env
+from app.api.routes.auth import issue_token, refresh_token
+from app.core.security import credential_fingerprint
+from app.models.schemas import RefreshTokenRequest, TokenRequest
+from app.services.api_key_service import api_key_service
+from app.services.refresh_token_service import refresh_token_service, RefreshTokenReuseError
+
+async def test_rotation_issues_a_new_token_in_the_same_family(prisma):
+    token = await refresh_token_service.issue("key:1", ["prices:read"], "fingerprint")
+
+    rotated, subject, scopes, credential_hash = await refresh_token_service.rotate(token)
+
+    assert rotated != token
+    assert (subject, scopes, credential_hash) == ("key:1", ["prices:read"], "fingerprint")
+    assert len({record.familyId for record in prisma.refreshtoken.records}) == 1
+
+async def test_reuse_revokes_the_whole_family(prisma):
+    token = await refresh_token_service.issue("key:1", ["prices:read"], "fingerprint")
+    rotated, *_ = await refresh_token_service.rotate(token)
+
+    with pytest.raises(RefreshTokenReuseError):
+        await refresh_token_service.rotate(token)
+
+    # The attacker's or the victim's copy, whichever came second, is now useless too
+    assert await refresh_token_service.rotate(rotated) is None
+    assert all(record.revokedAt is not None for record in prisma.refreshtoken.records)
+
+async def test_reuse_leaves_other_families_alone():
+    token = await refresh_token_service.issue("key:1", ["prices:read"], "fingerprint")
+    other = await refresh_token_service.issue("key:1", ["prices:read"], "fingerprint")
+    await refresh_token_service.rotate(token)
+
+    with pytest.raises(RefreshTokenReuseError):
+        await refresh_token_service.rotate(token)
+    assert await refresh_token_service.rotate(other) is not None
+
+async def test_expired_token_is_rejected(prisma):
+    token = await refresh_token_service.issue("key:1", ["prices:read"], "fingerprint")
+    prisma.refreshtoken.records[0].expiresAt = datetime.now(timezone.utc) - timedelta(seconds=1)
+
+    assert await refresh_token_service.rotate(token) is None
+
+async def test_refresh_endpoint_reports_reuse():
+    raw_key, _ = await api_key_service.create_key("ci", ["prices:read"])
+    issued = await issue_token(TokenRequest(api_key=raw_key))
+    await refresh_token(RefreshTokenRequest(refresh_token=issued.refresh_token))
+
+    with pytest.raises(HTTPException) as exc:
+        await refresh_token(RefreshTokenRequest(refresh_token=issued.refresh_token))
+    assert exc.value.status_code == 401
+
+async def test_refresh_keeps_the_narrowed_scopes():
+    raw_key, _ = await api_key_service.create_key("ci", ["prices:read", "alerts:read"])
+    issued = await issue_token(TokenRequest(api_key=raw_key, scopes=["prices:read"]))
+
+    refreshed = await refresh_token(RefreshTokenRequest(refresh_token=issued.refresh_token))
+
+    assert refreshed.scope == "prices:read"
+    assert refreshed.refresh_token not in (None, issued.refresh_token)
+
+async def test_revoking_the_api_key_ends_its_sessions(prisma):
+    raw_key, key = await api_key_service.create_key("ci", ["prices:read"])
+    issued = await issue_token(TokenRequest(api_key=raw_key))
+    await api_key_service.revoke_key(key.id)
+
+    with pytest.raises(HTTPException) as exc:
+        await refresh_token(RefreshTokenRequest(refresh_token=issued.refresh_token))
+    assert exc.value.status_code == 401
+    assert all(record.revokedAt is not None for record in prisma.refreshtoken.records)
+
+async def test_rotating_the_admin_key_ends_admin_sessions(monkeypatch):
+    monkeypatch.setattr(env, "ADMIN_API_KEY", "old-admin-key")
+    issued = await issue_token(TokenRequest(api_key="old-admin-key"))
+    monkeypatch.setattr(env, "ADMIN_API_KEY", "new-admin-key")
+
+    with pytest.raises(HTTPException) as exc:
+        await refresh_token(RefreshTokenRequest(refresh_token=issued.refresh_token))
+    assert exc.value.status_code == 401
+
+async def test_subjects_without_a_credential_get_no_refresh_token(prisma):
+    assert await credential_fingerprint("oidc:user-1") is None
+    assert await credential_fingerprint("hmac:ci") is None
+
+    # A refresh token minted without a fingerprint is refused on use
+    token = await refresh_token_service.issue("oidc:user-1", ["role:read-only"])
+    with pytest.raises(HTTPException) as exc:
+        await refresh_token(RefreshTokenRequest(refresh_token=token))
+    assert exc.value.status_code == 401
\ No newline at end of file
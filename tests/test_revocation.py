@@ -0,0 +1,55 @@
+from app.api.routes.auth import introspect_token, revoke_token
+from app.core.security import principal_from_token, token_signer
+from app.models.schemas import TokenIntrospectionRequest, TokenRevocationRequest
+from app.services.cache_service import MemoryBackend
+from app.services.token_revocation_service import token_revocation_service
+
+def issue() -> str:
+    return token_signer.issue("key:1", ["prices:read"])["access_token"]
+
+async def test_revoked_token_is_rejected():
+    token = issue()
+    assert await principal_from_token(token) is not None
+
+    await revoke_token(TokenRevocationRequest(token=token))
+
+    assert await principal_from_token(token) is None
+    assert (await introspect_token(TokenIntrospectionRequest(token=token))).active is False
+
+async def test_revocation_outlives_the_cache(cache, monkeypatch):
+    token = issue()
+    claims = token_signer.verify(token)
+    await token_revocation_service.revoke(claims["jti"], claims["sub"], claims["exp"])
+
+    # Another instance, or this one after a restart, only has the database
+    monkeypatch.setattr(cache, "backend", MemoryBackend(1000))
+
+    assert await principal_from_token(token) is None
+
+async def test_cached_revocation_expires_with_the_token(cache, monkeypatch):
+    token = issue()
+    claims = token_signer.verify(token)
+    await token_revocation_service.revoke(claims["jti"], claims["sub"], claims["exp"])
+    monkeypatch.setattr(cache, "backend", MemoryBackend(1000))
+
+    assert await token_revocation_service.is_revoked(claims["jti"])
+
+    _, expires_at = cache.backend._cache[f"revoked_jti:{claims['jti']}"]
+    assert expires_at is not None and expires_at <= claims["exp"] + 1
+
+async def test_other_tokens_stay_valid():
+    token, other = issue(), issue()
+
+    await revoke_token(TokenRevocationRequest(token=token))
+
+    assert await principal_from_token(other) is not None
+
+async def test_lookup_failure_fails_closed(prisma, monkeypatch):
+    async def unavailable(**kwargs):
+        raise RuntimeError("database unavailable")
+    monkeypatch.setattr(prisma.revokedtoken, "find_unique", unavailable)
+
+    assert await token_revocation_service.is_revoked("some-jti")
+
+async def test_unknown_token_revocation_is_not_an_error():
+    assert await revoke_token(TokenRevocationRequest(token="garbage")) == {}
\ No newline at end of file
@@ -0,0 +1,64 @@
+import time
+import pytest
+
+from env import env
+from app.core.signing import NONCE_HEADER, TIMESTAMP_HEADER, sign, string_to_sign, verify_signed_request
+
+CLIENT_ID = "ci"
+SECRET = "shared-hmac-secret"
+
+@pytest.fixture(autouse=True)
+def hmac_clients(monkeypatch):
+    monkeypatch.setattr(env, "HMAC_CLIENTS", {CLIENT_ID: {"secret": SECRET, "scopes": ["alerts:write"]}})
+
+def signed_request(make_request, nonce="nonce-1", body=b'{"symbol": "ETH"}', timestamp=None, secret=SECRET, signed_body=None):
+    timestamp = str(int(time.time())) if timestamp is None else timestamp
+    message = string_to_sign("POST", "/api/v1/alerts", "", timestamp, nonce, body if signed_body is None else signed_body)
+    return make_request("POST", "/api/v1/alerts", body=body, headers={
+        "Authorization": f"HMAC-SHA256 KeyId={CLIENT_ID}, Signature={sign(secret, message)}",
+        TIMESTAMP_HEADER: timestamp,
+        NONCE_HEADER: nonce,
+    })
+
+async def test_valid_signature_is_accepted(make_request):
+    assert await verify_signed_request(signed_request(make_request)) == (CLIENT_ID, ["alerts:write"])
+
+async def test_replayed_nonce_is_rejected(make_request):
+    assert await verify_signed_request(signed_request(make_request))
+
+    assert await verify_signed_request(signed_request(make_request)) is None
+    assert await verify_signed_request(signed_request(make_request, nonce="nonce-2"))
+
+async def test_stale_timestamp_is_rejected(make_request):
+    stale = str(int(time.time()) - env.HMAC_CLOCK_SKEW - 60)
+
+    assert await verify_signed_request(signed_request(make_request, timestamp=stale)) is None
+
+async def test_future_timestamp_is_rejected(make_request):
+    future = str(int(time.time()) + env.HMAC_CLOCK_SKEW + 60)
+
+    assert await verify_signed_request(signed_request(make_request, timestamp=future)) is None
+
+async def test_tampered_body_is_rejected(make_request):
+    request = signed_request(make_request, body=b'{"symbol": "BTC"}', signed_body=b'{"symbol": "ETH"}')
+
+    assert await verify_signed_request(request) is None
+
+async def test_wrong_secret_is_rejected(make_request):
+    assert await verify_signed_request(signed_request(make_request, secret="guessed")) is None
+
+async def test_bad_signature_does_not_burn_the_nonce(make_request):
+    assert await verify_signed_request(signed_request(make_request, secret="guessed")) is None
+
+    assert await verify_signed_request(signed_request(make_request))
+
+async def test_unknown_client_is_rejected(make_request, monkeypatch):
+    monkeypatch.setattr(env, "HMAC_CLIENTS", {})
+
+    assert await verify_signed_request(signed_request(make_request)) is None
+
+async def test_nonce_marker_outlives_the_skew_window(make_request, cache):
+    await verify_signed_request(signed_request(make_request))
+
+    _, expires_at = cache.backend._pinned[f"hmac_nonce:{CLIENT_ID}:nonce-1"]
+    assert expires_at >= time.time() + env.HMAC_CLOCK_SKEW
\ No newline at end of file
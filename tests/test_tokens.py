@@ -0,0 +1,70 @@
+from datetime import datetime, timedelta, timezone
+import pytest
+from fastapi import HTTPException
+from jose import jwt
+
+from env import env
+from app.api.routes.auth import issue_token
+from app.core.security import principal_from_token, token_signer
+from app.models.schemas import TokenRequest
+from app.services.api_key_service import api_key_service
+
+async def test_token_carries_the_key_scopes():
+    raw_key, key = await api_key_service.create_key("ci", ["prices:read", "alerts:read"])
+
+    response = await issue_token(TokenRequest(api_key=raw_key))
+
+    principal = await principal_from_token(response.access_token)
+    assert principal.subject == f"key:{key.id}"
+    assert principal.scopes == ["prices:read", "alerts:read"]
+
+async def test_token_can_be_narrowed():
+    raw_key, _ = await api_key_service.create_key("ci", ["prices:read", "alerts:read"])
+
+    response = await issue_token(TokenRequest(api_key=raw_key, scopes=["prices:read"]))
+
+    assert response.scope == "prices:read"
+    assert (await principal_from_token(response.access_token)).scopes == ["prices:read"]
+
+@pytest.mark.parametrize("requested", [
+    ["prices:read", "alerts:write"],
+    ["admin"],
+    ["role:operator"],
+    ["*:read"],
+])
+async def test_token_cannot_be_widened(requested):
+    raw_key, _ = await api_key_service.create_key("ci", ["prices:read", "alerts:read"])
+
+    with pytest.raises(HTTPException) as exc:
+        await issue_token(TokenRequest(api_key=raw_key, scopes=requested))
+    assert exc.value.status_code == 403
+
+async def test_unknown_api_key_is_rejected():
+    with pytest.raises(HTTPException) as exc:
+        await issue_token(TokenRequest(api_key="wdx_not-a-key"))
+    assert exc.value.status_code == 401
+
+async def test_expired_token_is_rejected():
+    token = token_signer.issue("key:1", ["prices:read"], ttl=-60)["access_token"]
+
+    assert await principal_from_token(token) is None
+
+async def test_token_signed_with_another_key_is_rejected():
+    now = datetime.now(timezone.utc)
+    claims = {
+        "iss": env.JWT_ISSUER,
+        "sub": "admin",
+        "scope": "admin",
+        "exp": int((now + timedelta(minutes=5)).timestamp()),
+        "jti": "forged",
+    }
+    token = jwt.encode(claims, "not-the-secret", algorithm="HS256")
+
+    assert await principal_from_token(token) is None
+
+async def test_token_from_another_issuer_is_rejected():
+    claims = token_signer.verify(token_signer.issue("key:1", ["prices:read"])["access_token"])
+    claims["iss"] = "someone-else"
+    token = jwt.encode(claims, env.JWT_SECRET, algorithm="HS256")
+
+    assert await principal_from_token(token) is None
\ No newline at end of file
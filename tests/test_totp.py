@@ -0,0 +1,68 @@
+from datetime import datetime, timezone
+import asyncio
+import time
+import pyotp
+import pytest
+from fastapi import HTTPException
+
+from app.core.security import Principal, require_totp
+from app.services.totp_service import totp_service
+
+SUBJECT = "key:1"
+
+@pytest.fixture
+def secret() -> str:
+    return pyotp.random_base32()
+
+@pytest.fixture
+async def enrolled(prisma, secret) -> str:
+    await prisma.totpenrollment.create(
+        data={"subject": SUBJECT, "secret": secret, "enabledAt": datetime.now(timezone.utc)}
+    )
+    return secret
+
+async def test_code_is_accepted_once(secret):
+    code = pyotp.TOTP(secret).now()
+
+    assert await totp_service._check(SUBJECT, secret, code)
+    assert not await totp_service._check(SUBJECT, secret, code)
+
+async def test_concurrent_use_of_a_code_succeeds_once(secret):
+    code = pyotp.TOTP(secret).now()
+
+    results = await asyncio.gather(*(totp_service._check(SUBJECT, secret, code) for _ in range(10)))
+
+    assert results.count(True) == 1
+
+async def test_stale_and_malformed_codes_are_rejected(secret):
+    stale = pyotp.TOTP(secret).at(time.time() - 3600)
+
+    for code in (stale, "", "abcdef"):
+        assert not await totp_service._check(SUBJECT, secret, code)
+
+async def test_confirmation_code_cannot_be_replayed(prisma, secret):
+    await prisma.totpenrollment.create(data={"subject": SUBJECT, "secret": secret})
+    code = pyotp.TOTP(secret).now()
+
+    assert await totp_service.confirm_enrollment(SUBJECT, code)
+    assert await totp_service.is_enrolled(SUBJECT)
+    assert not await totp_service.verify(SUBJECT, code)
+
+async def test_pending_enrollment_does_not_verify(prisma, secret):
+    await prisma.totpenrollment.create(data={"subject": SUBJECT, "secret": secret})
+
+    assert not await totp_service.verify(SUBJECT, pyotp.TOTP(secret).now())
+
+async def test_enabled_enrollment_cannot_be_replaced(enrolled):
+    assert await totp_service.start_enrollment(SUBJECT) is None
+
+async def test_enrolled_callers_need_a_code(make_request, enrolled):
+    request = make_request("POST", "/api/v1/keys")
+    request.state.principal = Principal(subject=SUBJECT, scopes=["keys:manage"])
+
+    for code in (None, pyotp.TOTP(enrolled).at(time.time() - 3600)):
+        with pytest.raises(HTTPException) as exc:
+            await require_totp(request, code)
+        assert exc.value.status_code == 403
+
+    await require_totp(request, pyotp.TOTP(enrolled).now())
\ No newline at end of file